@@ -0,0 +1,150 @@
+// Command normalize-phones mengubah nomor HP lama di database ke format E.164.
+//
+// Default-nya dry run (hanya laporan). Jalankan dengan -apply untuk menyimpan:
+//
+//	go run ./cmd/normalize-phones -apply
+//
+// Nomor yang setelah dinormalisasi sama dengan baris lain (collision) tidak diubah,
+// hanya dilaporkan supaya bisa di-merge manual.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/utils"
+	"gorm.io/gorm"
+)
+
+type phoneColumn struct {
+	Table  string
+	Column string
+	Unique bool // nomor dipakai sebagai identitas (lookup login / user)
+}
+
+var columns = []phoneColumn{
+	{Table: "users", Column: "phone", Unique: true},
+	{Table: "user_accounts", Column: "phone", Unique: true},
+	{Table: "addresses", Column: "phone"},
+	{Table: "orders", Column: "address_phone"},
+}
+
+type phoneRow struct {
+	ID    string
+	Phone string
+}
+
+type report struct {
+	Updated    int
+	Unchanged  int
+	Invalid    []phoneRow
+	Collisions map[string][]phoneRow
+}
+
+func main() {
+	apply := flag.Bool("apply", false, "simpan perubahan (tanpa flag ini hanya dry run)")
+	flag.Parse()
+
+	config.ConnectDB()
+
+	collisions := 0
+	for _, col := range columns {
+		r, err := normalizeColumn(config.DB, col, *apply)
+		if err != nil {
+			log.Fatalf("❌ %s.%s: %v", col.Table, col.Column, err)
+		}
+		printReport(col, r, *apply)
+		collisions += len(r.Collisions)
+	}
+
+	if !*apply {
+		fmt.Println("ℹ️  Dry run, tidak ada yang disimpan. Jalankan dengan -apply untuk menyimpan.")
+	}
+	if collisions > 0 {
+		fmt.Printf("⚠️  %d nomor bentrok, selesaikan manual (merge customer) lalu jalankan ulang.\n", collisions)
+	}
+}
+
+func normalizeColumn(db *gorm.DB, col phoneColumn, apply bool) (report, error) {
+	r := report{Collisions: map[string][]phoneRow{}}
+
+	// termasuk baris soft delete, karena unique index tetap berlaku untuk baris itu
+	var rows []phoneRow
+	err := db.Table(col.Table).
+		Select(fmt.Sprintf("id, %s AS phone", col.Column)).
+		Where(fmt.Sprintf("COALESCE(%s, '') <> ''", col.Column)).
+		Scan(&rows).Error
+	if err != nil {
+		return r, err
+	}
+
+	// kelompokkan per nomor hasil normalisasi untuk deteksi collision
+	groups := map[string][]phoneRow{}
+	for _, row := range rows {
+		normalized, err := utils.NormalizePhone(row.Phone)
+		if err != nil {
+			r.Invalid = append(r.Invalid, row)
+			continue
+		}
+		groups[normalized] = append(groups[normalized], row)
+	}
+
+	updates := map[string]string{} // id -> nomor baru
+	for normalized, group := range groups {
+		if col.Unique && len(group) > 1 {
+			r.Collisions[normalized] = group
+			continue
+		}
+		for _, row := range group {
+			if row.Phone == normalized {
+				r.Unchanged++
+				continue
+			}
+			updates[row.ID] = normalized
+		}
+	}
+	r.Updated = len(updates)
+
+	if !apply || len(updates) == 0 {
+		return r, nil
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for id, phone := range updates {
+			if err := tx.Table(col.Table).Where("id = ?", id).Update(col.Column, phone).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return r, err
+}
+
+func printReport(col phoneColumn, r report, apply bool) {
+	verb := "akan diubah"
+	if apply {
+		verb = "diubah"
+	}
+	fmt.Printf("📞 %s.%s: %d %s, %d sudah E.164, %d tidak valid, %d collision\n",
+		col.Table, col.Column, r.Updated, verb, r.Unchanged, len(r.Invalid), len(r.Collisions))
+
+	for _, row := range r.Invalid {
+		fmt.Printf("   ✖ tidak valid  id=%s phone=%q\n", row.ID, row.Phone)
+	}
+
+	phones := make([]string, 0, len(r.Collisions))
+	for phone := range r.Collisions {
+		phones = append(phones, phone)
+	}
+	sort.Strings(phones)
+	for _, phone := range phones {
+		var parts []string
+		for _, row := range r.Collisions[phone] {
+			parts = append(parts, fmt.Sprintf("%s (%q)", row.ID, row.Phone))
+		}
+		fmt.Printf("   ⚠ collision %s: %s\n", phone, strings.Join(parts, ", "))
+	}
+}
@@ -18,6 +18,11 @@ import (
 
 var DB *gorm.DB
 
+// DevMode true kalau APP_ENV=development. Hanya di mode ini secret bawaan (JWT, OTP) boleh dipakai.
+func DevMode() bool {
+	return os.Getenv("APP_ENV") == "development"
+}
+
 func ConnectDB() {
 	// Load .env (hanya jika belum jalan di Docker / CI)
 	if os.Getenv("RUNNING_IN_DOCKER") == "" {
@@ -50,9 +55,31 @@ func ConnectDB() {
 		&models.Product{},
 		&models.Order{},
 		&models.OrderStatusUpdate{},
+		&models.Permission{},
+		&models.Role{},
 		&models.UserAccount{},
 		&models.Notification{},
 		&models.Info{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.OTPCode{},
+		&models.PasswordResetToken{},
+		&models.PasswordChangeLog{},
+		&models.APIKey{},
+		&models.RecoveryCode{},
+		&models.AuditLog{},
+		&models.Address{},
+		&models.CustomerTag{},
+		&models.CustomerNote{},
+		&models.Company{},
+		&models.CompanyMember{},
+		&models.ProductPrice{},
+		&models.ProductOptionGroup{},
+		&models.ProductOptionValue{},
+		&models.ProductVariant{},
+		&models.PricingFormula{},
+		&models.OrderQuote{},
 	)
 
 	if err != nil {
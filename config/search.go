@@ -0,0 +1,30 @@
+package config
+
+// ProductSearchReady true kalau extension unaccent & pg_trgm berhasil dipasang.
+// Kalau false (mis. user DB tidak boleh CREATE EXTENSION), pencarian produk fallback ke ILIKE.
+var ProductSearchReady bool
+
+// SetupProductSearch memasang extension + fungsi f_unaccent (IMMUTABLE, supaya bisa dipakai
+// di index) dan index full-text / trigram untuk pencarian produk.
+func SetupProductSearch() error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS unaccent`,
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text AS
+			$$ SELECT public.unaccent('public.unaccent', $1) $$
+			LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT`,
+		`CREATE INDEX IF NOT EXISTS idx_products_search ON products
+			USING GIN (to_tsvector('simple', f_unaccent(coalesce(name, '') || ' ' || coalesce(detail, ''))))`,
+		`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products
+			USING GIN (f_unaccent(lower(name)) gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_categories_name_trgm ON categories
+			USING GIN (f_unaccent(lower(name)) gin_trgm_ops)`,
+	}
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	ProductSearchReady = true
+	return nil
+}
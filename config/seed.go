@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ary/go-api/models"
+	"gorm.io/gorm"
+)
+
+// SeedRoles memastikan role & permission bawaan ada di database.
+// Role yang sudah ada tidak ditimpa (permission-nya bisa diubah admin),
+// tapi permission yang baru pertama kali dibuat otomatis ditambahkan ke role bawaan yang memakainya.
+// Setelah itu akun lama (kolom teks role) diisi role_id-nya.
+func SeedRoles(defaults []models.Role, fallbackRole string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		permissions := map[string]models.Permission{}
+		newPermissions := map[string]bool{}
+
+		for _, role := range defaults {
+			for _, p := range role.Permissions {
+				if _, ok := permissions[p.Name]; ok {
+					continue
+				}
+				var perm models.Permission
+				err := tx.Where("name = ?", p.Name).First(&perm).Error
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					perm = models.Permission{Name: p.Name, Description: p.Description}
+					if err := tx.Create(&perm).Error; err != nil {
+						return err
+					}
+					newPermissions[p.Name] = true
+				} else if err != nil {
+					return err
+				}
+				permissions[p.Name] = perm
+			}
+		}
+
+		for _, def := range defaults {
+			var role models.Role
+			err := tx.Where("name = ?", def.Name).First(&role).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				role = models.Role{Name: def.Name, Description: def.Description, IsStaff: def.IsStaff}
+				if err := tx.Create(&role).Error; err != nil {
+					return err
+				}
+				// role baru → semua permission bawaan
+				if err := tx.Model(&role).Association("Permissions").Append(rolePermissions(def, permissions, nil)); err != nil {
+					return err
+				}
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			if perms := rolePermissions(def, permissions, newPermissions); len(perms) > 0 {
+				if err := tx.Model(&role).Association("Permissions").Append(perms); err != nil {
+					return err
+				}
+			}
+		}
+
+		return backfillAccountRoles(tx, fallbackRole)
+	})
+}
+
+// rolePermissions memilih permission milik role; kalau only != nil hanya yang ada di only
+func rolePermissions(def models.Role, all map[string]models.Permission, only map[string]bool) []models.Permission {
+	perms := []models.Permission{}
+	for _, p := range def.Permissions {
+		if only != nil && !only[p.Name] {
+			continue
+		}
+		perms = append(perms, all[p.Name])
+	}
+	return perms
+}
+
+// backfillAccountRoles mengisi role_id dari kolom lama user_accounts.role (teks bebas).
+// "user" / kosong / role yang tidak dikenal dianggap fallbackRole (customer).
+func backfillAccountRoles(tx *gorm.DB, fallbackRole string) error {
+	if tx.Migrator().HasColumn("user_accounts", "role") {
+		if err := tx.Exec(`
+			UPDATE user_accounts ua SET role_id = r.id
+			FROM roles r
+			WHERE ua.role_id IS NULL
+			  AND r.name = LOWER(TRIM(COALESCE(ua.role, '')))`).Error; err != nil {
+			return fmt.Errorf("backfill account roles: %w", err)
+		}
+	}
+
+	return tx.Exec(`
+		UPDATE user_accounts SET role_id = (SELECT id FROM roles WHERE name = ?)
+		WHERE role_id IS NULL`, fallbackRole).Error
+}
+
+// BackfillAddresses membuat alamat default dari kolom alamat di users
+// untuk user yang belum punya buku alamat (data sebelum fitur alamat).
+func BackfillAddresses() error {
+	return DB.Exec(`
+		INSERT INTO addresses (id, user_id, label, recipient_name, phone, address, regency, district, lat, lang, is_default, created_at, updated_at)
+		SELECT gen_random_uuid(), u.id, 'Alamat utama', u.name, u.phone, u.address, u.regency, u.district, u.lat, u.lang, TRUE, NOW(), NOW()
+		FROM users u
+		WHERE u.deleted_at IS NULL
+		  AND COALESCE(TRIM(u.address), '') <> ''
+		  AND NOT EXISTS (SELECT 1 FROM addresses a WHERE a.user_id = u.id)`).Error
+}
@@ -0,0 +1,312 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AddressInput struct {
+	Label         string   `json:"label" example:"Rumah"`
+	RecipientName string   `json:"recipient_name" example:"Budi"`
+	Phone         string   `json:"phone" example:"08123456789"`
+	Address       string   `json:"address" example:"Jl. Mawar No. 123"`
+	Regency       string   `json:"regency" example:"Cengkareng"`
+	District      string   `json:"district" example:"Duri Kosambi"`
+	Notes         string   `json:"notes" example:"Pagar hitam"`
+	Lat           *float64 `json:"lat"`
+	Lang          *float64 `json:"lang"`
+	IsDefault     *bool    `json:"is_default"`
+}
+
+// GetMyAddresses godoc
+// @Summary      List my addresses
+// @Tags         Me
+// @Produce      json
+// @Success      200 {array} models.Address
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /me/addresses [get]
+func GetMyAddresses(c *gin.Context) {
+	var addresses []models.Address
+	if err := config.DB.Where("user_id = ?", currentUserID(c)).
+		Order("is_default DESC, created_at ASC").
+		Find(&addresses).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch addresses", nil)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", addresses)
+}
+
+// GetMyAddress godoc
+// @Summary      Get my address
+// @Tags         Me
+// @Produce      json
+// @Param        id path string true "Address ID"
+// @Success      200 {object} models.Address
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /me/addresses/{id} [get]
+func GetMyAddress(c *gin.Context) {
+	address, ok := findMyAddress(c)
+	if !ok {
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", address)
+}
+
+// CreateMyAddress godoc
+// @Summary      Add address
+// @Description  Alamat pertama otomatis jadi default
+// @Tags         Me
+// @Accept       json
+// @Produce      json
+// @Param        address body AddressInput true "Alamat"
+// @Success      201 {object} models.Address
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /me/addresses [post]
+func CreateMyAddress(c *gin.Context) {
+	var input AddressInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Address == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "address is required", nil)
+		return
+	}
+	if !normalizeInputPhone(c, &input.Phone) {
+		return
+	}
+
+	userID := currentUserID(c)
+	address := models.Address{UserID: userID}
+	applyAddressInput(&address, input)
+	if address.Label == "" {
+		address.Label = "Alamat"
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Address{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			address.IsDefault = true
+		}
+
+		if err := tx.Create(&address).Error; err != nil {
+			return err
+		}
+		if address.IsDefault {
+			return setDefaultAddress(tx, address)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to save address", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, "Address saved", address)
+}
+
+// UpdateMyAddress godoc
+// @Summary      Update address
+// @Tags         Me
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Address ID"
+// @Param        address body AddressInput true "Field yang diubah"
+// @Success      200 {object} models.Address
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /me/addresses/{id} [patch]
+func UpdateMyAddress(c *gin.Context) {
+	var input AddressInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+	if !normalizeInputPhone(c, &input.Phone) {
+		return
+	}
+
+	address, ok := findMyAddress(c)
+	if !ok {
+		return
+	}
+	wasDefault := address.IsDefault
+	applyAddressInput(&address, input)
+	if wasDefault && !address.IsDefault {
+		// default hanya bisa dipindah dengan menjadikan alamat lain default
+		address.IsDefault = true
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&address).Error; err != nil {
+			return err
+		}
+		if address.IsDefault {
+			return setDefaultAddress(tx, address)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update address", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Address updated", address)
+}
+
+// SetMyDefaultAddress godoc
+// @Summary      Set default address
+// @Tags         Me
+// @Produce      json
+// @Param        id path string true "Address ID"
+// @Success      200 {object} models.Address
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /me/addresses/{id}/default [post]
+func SetMyDefaultAddress(c *gin.Context) {
+	address, ok := findMyAddress(c)
+	if !ok {
+		return
+	}
+
+	address.IsDefault = true
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return setDefaultAddress(tx, address)
+	}); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update address", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Default address updated", address)
+}
+
+// DeleteMyAddress godoc
+// @Summary      Delete address
+// @Description  Order lama tidak terpengaruh karena menyimpan salinan alamat. Kalau yang dihapus alamat default, alamat terbaru jadi default.
+// @Tags         Me
+// @Produce      json
+// @Param        id path string true "Address ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /me/addresses/{id} [delete]
+func DeleteMyAddress(c *gin.Context) {
+	address, ok := findMyAddress(c)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+
+		var next models.Address
+		err := tx.Where("user_id = ?", address.UserID).Order("created_at DESC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		next.IsDefault = true
+		return setDefaultAddress(tx, next)
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to delete address", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Address deleted", gin.H{"id": address.ID})
+}
+
+// --- helpers ---
+
+func findMyAddress(c *gin.Context) (models.Address, bool) {
+	var address models.Address
+	id := utils.ParseUUID(c.Param("id"))
+	if id == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid address ID", nil)
+		return address, false
+	}
+	if err := config.DB.First(&address, "id = ? AND user_id = ?", id, currentUserID(c)).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Address not found", nil)
+		return address, false
+	}
+	return address, true
+}
+
+func applyAddressInput(address *models.Address, input AddressInput) {
+	if input.Label != "" {
+		address.Label = input.Label
+	}
+	if input.RecipientName != "" {
+		address.RecipientName = input.RecipientName
+	}
+	if input.Phone != "" {
+		address.Phone = input.Phone
+	}
+	if input.Address != "" {
+		address.Address = input.Address
+	}
+	if input.Regency != "" {
+		address.Regency = input.Regency
+	}
+	if input.District != "" {
+		address.District = input.District
+	}
+	if input.Notes != "" {
+		address.Notes = input.Notes
+	}
+	if input.Lat != nil {
+		address.Lat = *input.Lat
+	}
+	if input.Lang != nil {
+		address.Lang = *input.Lang
+	}
+	if input.IsDefault != nil {
+		address.IsDefault = *input.IsDefault
+	}
+}
+
+// setDefaultAddress menjadikan address satu-satunya default milik user, dan menyalin
+// isinya ke kolom alamat di users (dipakai client lama & claim JWT)
+func setDefaultAddress(tx *gorm.DB, address models.Address) error {
+	if err := tx.Model(&models.Address{}).
+		Where("user_id = ? AND id <> ?", address.UserID, address.ID).
+		Update("is_default", false).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Address{}).Where("id = ?", address.ID).Update("is_default", true).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.User{}).Where("id = ?", address.UserID).Updates(map[string]interface{}{
+		"address":  address.Address,
+		"regency":  address.Regency,
+		"district": address.District,
+		"lat":      address.Lat,
+		"lang":     address.Lang,
+	}).Error
+}
+
+// snapshotAddress menyalin isi alamat ke order
+func snapshotAddress(order *models.Order, address models.Address) {
+	order.AddressID = &address.ID
+	order.Address = address.Address
+	order.AddressLabel = address.Label
+	order.AddressName = address.RecipientName
+	order.AddressPhone = address.Phone
+	order.AddressRegency = address.Regency
+	order.AddressDistrict = address.District
+	order.AddressNotes = address.Notes
+	order.AddressLat = address.Lat
+	order.AddressLang = address.Lang
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateAPIKey godoc
+// @Summary      Create API key
+// @Description  Buat API key untuk client mesin. Key lengkap hanya dikembalikan sekali di response ini.
+// @Tags         API Keys
+// @Accept       json
+// @Produce      json
+// @Param        input body object{name=string,scopes=[]string,expires_at=string} true "Nama, scope dan waktu kadaluarsa (RFC3339, opsional)"
+// @Success      201 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /admin/api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	var input struct {
+		Name      string     `json:"name" binding:"required"`
+		Scopes    []string   `json:"scopes" binding:"required,min=1"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	if input.ExpiresAt != nil && input.ExpiresAt.Before(time.Now()) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "expires_at must be in the future", nil)
+		return
+	}
+
+	// scope harus permission yang dikenal; kelola staff / API key tidak boleh lewat API key
+	var count int64
+	if err := config.DB.Model(&models.Permission{}).Where("name IN ?", input.Scopes).Count(&count).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to check scopes", nil)
+		return
+	}
+	if int(count) != len(input.Scopes) || containsString(input.Scopes, utils.PermStaffManage) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid scope in list", nil)
+		return
+	}
+
+	key, prefix, hash, err := utils.GenerateAPIKey()
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to generate API key", nil)
+		return
+	}
+
+	apiKey := models.APIKey{
+		Name:      input.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+		CreatedBy: currentUserID(c),
+	}
+	if err := config.DB.Create(&apiKey).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create API key", nil)
+		return
+	}
+
+	log.Printf("🔑 API key created: id=%s name=%s scopes=%v by=%s", apiKey.ID, apiKey.Name, input.Scopes, c.GetString("user_id"))
+	utils.SendSuccessResponse(c, http.StatusCreated, "API key created, simpan key ini karena tidak akan ditampilkan lagi", gin.H{
+		"key":     key,
+		"api_key": apiKey,
+	})
+}
+
+// GetAPIKeys godoc
+// @Summary      List API keys
+// @Tags         API Keys
+// @Produce      json
+// @Success      200 {array} models.APIKey
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /admin/api-keys [get]
+func GetAPIKeys(c *gin.Context) {
+	var keys []models.APIKey
+	if err := config.DB.Order("created_at DESC").Find(&keys).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch API keys", nil)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke API key
+// @Tags         API Keys
+// @Produce      json
+// @Param        id path string true "API Key ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/api-keys/{id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	id := utils.ParseUUID(c.Param("id"))
+	if id == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid API key ID", nil)
+		return
+	}
+
+	var apiKey models.APIKey
+	if err := config.DB.First(&apiKey, "id = ?", id).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "API key not found", nil)
+		return
+	}
+
+	if apiKey.RevokedAt == nil {
+		now := time.Now()
+		if err := config.DB.Model(&apiKey).Update("revoked_at", now).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to revoke API key", nil)
+			return
+		}
+		apiKey.RevokedAt = &now
+		log.Printf("🔑 API key revoked: id=%s by=%s", apiKey.ID, c.GetString("user_id"))
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "API key revoked", apiKey)
+}
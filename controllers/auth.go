@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/ary/go-api/config"
 	"github.com/ary/go-api/models"
@@ -10,6 +13,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// hash palsu untuk menyamakan waktu respons saat nomor tidak terdaftar
+var dummyPasswordHash = func() string {
+	hash, err := utils.HashPassword("dummy-password-for-timing")
+	if err != nil {
+		log.Println("⚠️ Failed to create dummy password hash:", err)
+	}
+	return hash
+}()
+
 func Login(c *gin.Context) {
 	var input struct {
 		Phone    string `json:"phone" binding:"required"`
@@ -21,39 +33,246 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// 0812..., +62812... dst. dianggap nomor yang sama (juga untuk counter lockout)
+	input.Phone = utils.NormalizePhoneOrRaw(input.Phone)
+
+	ip := c.ClientIP()
+	if until, locked := utils.LoginLockedUntil(input.Phone, ip); locked {
+		retryAfter := int(time.Until(until).Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many failed login attempts, try again later",
+			"retry_after": retryAfter,
+		})
+		return
+	}
+
+	// pesan error sengaja disamakan supaya nomor HP tidak bisa dienumerasi
 	var account models.UserAccount
-	if err := config.DB.Preload("User").First(&account, "phone = ?", input.Phone).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+	found := config.DB.Preload("User").Preload("Role").First(&account, "phone = ?", input.Phone).Error == nil
+	hash := dummyPasswordHash
+	if found {
+		hash = account.PasswordHash
+	}
+
+	// cek password (tetap jalan walau user tidak ada, supaya waktu respons sama)
+	if !utils.CheckPasswordHash(hash, input.Password) || !found {
+		failures := utils.RegisterLoginFailure(input.Phone, ip)
+		time.Sleep(utils.LoginDelay(failures))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid phone or password"})
+		return
+	}
+
+	if account.DeactivatedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account deactivated"})
+		return
+	}
+
+	// hash lama (bcrypt / parameter lama) diganti ke versi sekarang selagi password-nya ada
+	if utils.PasswordNeedsRehash(account.PasswordHash) {
+		rehashPassword(account, input.Password)
+	}
+
+	// akun dengan 2FA: counter gagal baru di-reset setelah langkah kedua berhasil
+	challenge, needed, err := secondFactorChallenge(account)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start 2FA"})
+		return
+	}
+	if needed {
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	utils.ResetLoginFailures(input.Phone)
+	respondLogin(c, account, nil)
+}
+
+func rehashPassword(account models.UserAccount, password string) {
+	hash, err := utils.HashPassword(password)
+	if err == nil {
+		err = config.DB.Model(&models.UserAccount{}).
+			Where("id = ? AND password_hash = ?", account.ID, account.PasswordHash).
+			UpdateColumn("password_hash", hash).Error
+	}
+	if err != nil {
+		log.Println("⚠️ Failed to rehash password:", err)
+	}
+}
+
+// respondLogin membuat session + token lalu mengirim respons login sukses
+func respondLogin(c *gin.Context, account models.UserAccount, extra gin.H) {
+	// generate access token + refresh token (session / family baru per login)
+	accessToken, refreshToken, err := startSession(c, account)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	body := gin.H{
+		"message":       "Login success",
+		"token":         accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+
+	setAccessTokenCookie(c, accessToken)
+	c.JSON(http.StatusOK, body)
+}
+
+// RefreshToken godoc
+// @Summary      Refresh access token
+// @Description  Tukar refresh token dengan access token baru. Refresh token lama tidak bisa dipakai lagi.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        input body object{refresh_token=string} true "Refresh token"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Router       /token/refresh [post]
+func RefreshToken(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
 
-	// cek password
-	if !utils.CheckPasswordHash(account.PasswordHash, input.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Wrong password"})
+	rec, newRefreshToken, err := utils.RotateRefreshToken(input.RefreshToken)
+	if err != nil {
+		if errors.Is(err, utils.ErrRefreshTokenInvalid) || errors.Is(err, utils.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		log.Println("❌ Failed to rotate refresh token:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	var account models.UserAccount
+	if err := config.DB.Preload("User").Preload("Role").First(&account, "user_id = ?", rec.UserID).Error; err != nil || account.DeactivatedAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
 		return
 	}
 
-	// generate token dengan data user
-	token, err := utils.GenerateJWT(
-		account.User.ID.String(),
-		account.Phone,
-		account.User.Name,
-		account.User.Email,
-		account.User.Address,
-		account.User.Regency,
-		account.User.District,
-		strconv.FormatFloat(account.User.Lat, 'f', -1, 64),
-		strconv.FormatFloat(account.User.Lang, 'f', -1, 64),
-		account.User.PhotoUrl,
-		account.Role,
-	)
+	accessToken, err := utils.GenerateAccessToken(accountClaims(account, rec.FamilyID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
+	utils.TouchSession(rec.FamilyID, c.ClientIP())
+
+	setAccessTokenCookie(c, accessToken)
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login success",
-		"token":   token,
+		"message":       "Token refreshed",
+		"token":         accessToken,
+		"refresh_token": newRefreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
 	})
 }
+
+// Logout godoc
+// @Summary      Logout
+// @Description  Cabut access token yang sedang dipakai dan semua refresh token dari sesi login ini
+// @Tags         Auth
+// @Produce      json
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Router       /logout [post]
+func Logout(c *gin.Context) {
+	claims := currentClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+
+	if claims.ExpiresAt != nil {
+		if err := utils.RevokeAccessToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+			log.Println("❌ Failed to revoke access token:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+			return
+		}
+	}
+
+	// token impersonation memakai sid milik admin, jadi cukup jti-nya yang dicabut
+	if claims.SessionID != "" && claims.Act == nil {
+		if err := utils.RevokeRefreshFamily(claims.SessionID); err != nil {
+			log.Println("❌ Failed to revoke refresh tokens:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+			return
+		}
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(utils.AccessTokenCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+	c.JSON(http.StatusOK, gin.H{"message": "Logout success"})
+}
+
+// setAccessTokenCookie menyimpan access token di cookie HttpOnly.
+// Cookie ini hanya dipakai untuk koneksi SSE / WebSocket dari browser, bukan untuk API biasa.
+func setAccessTokenCookie(c *gin.Context, accessToken string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(utils.AccessTokenCookie, accessToken, int(utils.AccessTokenTTL.Seconds()), "/", "", c.Request.TLS != nil, true)
+}
+
+// currentClaims mengambil claims JWT yang disimpan AuthMiddleware
+func currentClaims(c *gin.Context) *utils.Claims {
+	value, _ := c.Get("claims")
+	claims, _ := value.(*utils.Claims)
+	return claims
+}
+
+// accountClaims menyusun claims JWT dari data account + user
+func accountClaims(account models.UserAccount, sessionID string) *utils.Claims {
+	return &utils.Claims{
+		UserID:    account.User.ID.String(),
+		Phone:     account.Phone,
+		Name:      account.User.Name,
+		Email:     account.User.Email,
+		Address:   account.User.Address,
+		Regency:   account.User.Regency,
+		District:  account.User.District,
+		Lang:      strconv.FormatFloat(account.User.Lang, 'f', -1, 64),
+		Lat:       strconv.FormatFloat(account.User.Lat, 'f', -1, 64),
+		PhotoUrl:  account.User.PhotoUrl,
+		Role:      account.Role.Name,
+		IsActive:  account.User.IsActive,
+		SessionID: sessionID,
+	}
+}
+
+// startSession mencatat session (perangkat) baru lalu membuat pasangan token untuknya
+func startSession(c *gin.Context, account models.UserAccount) (string, string, error) {
+	device := c.GetHeader("X-Device-Name")
+	if device == "" {
+		device = utils.DeviceName(c.Request.UserAgent())
+	}
+
+	sessionID := utils.NewFamilyID()
+	if err := utils.CreateSession(account.User.ID.String(), sessionID, device, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		return "", "", err
+	}
+	return issueTokenPair(account, sessionID)
+}
+
+// issueTokenPair membuat access token + refresh token untuk 1 sesi login
+func issueTokenPair(account models.UserAccount, sessionID string) (string, string, error) {
+	accessToken, err := utils.GenerateAccessToken(accountClaims(account, sessionID))
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err := utils.IssueRefreshToken(account.User.ID.String(), sessionID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
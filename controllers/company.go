@@ -0,0 +1,659 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Company B2B: anggota owner bisa kelola company & anggota, purchaser hanya order.
+// Semua anggota bisa melihat order & notifikasi company.
+const (
+	companyRoleOwner     = "owner"
+	companyRolePurchaser = "purchaser"
+
+	// anggota yang ditambahkan owner harus menerima undangannya dulu
+	companyMemberActive  = "active"
+	companyMemberInvited = "invited"
+)
+
+func validCompanyRole(role string) bool {
+	return role == companyRoleOwner || role == companyRolePurchaser
+}
+
+// companyRole role user di company, "" kalau bukan anggota
+func companyRole(companyID, userID uuid.UUID) string {
+	if userID == uuid.Nil {
+		return ""
+	}
+	var member models.CompanyMember
+	if err := config.DB.First(&member, "company_id = ? AND user_id = ? AND status = ?", companyID, userID, companyMemberActive).Error; err != nil {
+		return ""
+	}
+	return member.Role
+}
+
+// companyMemberIDs user_id semua anggota company (untuk broadcast notifikasi)
+func companyMemberIDs(companyID uuid.UUID) []uuid.UUID {
+	var ids []uuid.UUID
+	config.DB.Model(&models.CompanyMember{}).Where("company_id = ? AND status = ?", companyID, companyMemberActive).Pluck("user_id", &ids)
+	return ids
+}
+
+// loadCompany membaca company dari :id lalu cek akses: staff dengan perm,
+// atau anggota company (kalau ownerOnly, harus owner).
+func loadCompany(c *gin.Context, perm string, ownerOnly bool) (models.Company, bool) {
+	var company models.Company
+	id := utils.ParseUUID(c.Param("id"))
+	if id == uuid.Nil || config.DB.First(&company, "id = ?", id).Error != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Company not found", nil)
+		return company, false
+	}
+	if utils.CurrentPrincipal(c).Can(perm) {
+		return company, true
+	}
+
+	role := companyRole(company.ID, currentUserID(c))
+	switch {
+	case role == "":
+		// bukan anggota: jangan bocorkan bahwa company-nya ada
+		utils.SendErrorResponse(c, http.StatusNotFound, "Company not found", nil)
+		return company, false
+	case ownerOnly && role != companyRoleOwner:
+		utils.SendErrorResponse(c, http.StatusForbidden, "Only company owners can do this", nil)
+		return company, false
+	}
+	return company, true
+}
+
+// CompanyInput body create / update company
+type CompanyInput struct {
+	Name            string `json:"name" example:"CV Maju Jaya"`
+	NPWP            string `json:"npwp" example:"01.234.567.8-901.000"`
+	Email           string `json:"email" example:"purchasing@majujaya.co.id"`
+	Phone           string `json:"phone" example:"0215551234"`
+	BillingAddress  string `json:"billing_address" example:"Jl. Daan Mogot No. 10"`
+	BillingRegency  string `json:"billing_regency" example:"Jakarta Barat"`
+	BillingDistrict string `json:"billing_district" example:"Cengkareng"`
+	OwnerUserID     string `json:"owner_user_id" example:"4cd64505-f56f-421b-9243-e6bc9cbbaa7b"` // hanya staff, default user yang login
+}
+
+func applyCompanyInput(c *gin.Context, company *models.Company, input CompanyInput) bool {
+	if input.NPWP != "" {
+		npwp, err := utils.NormalizeNPWP(input.NPWP)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "NPWP must be 15 or 16 digits", nil)
+			return false
+		}
+		var count int64
+		config.DB.Model(&models.Company{}).Where("npwp = ? AND id <> ?", npwp, company.ID).Count(&count)
+		if count > 0 {
+			utils.SendErrorResponse(c, http.StatusConflict, "NPWP already registered", nil)
+			return false
+		}
+		company.NPWP = npwp
+	}
+	if !normalizeInputPhone(c, &input.Phone) {
+		return false
+	}
+
+	if name := strings.TrimSpace(input.Name); name != "" {
+		company.Name = name
+	}
+	if input.Email != "" {
+		company.Email = input.Email
+	}
+	if input.Phone != "" {
+		company.Phone = input.Phone
+	}
+	if input.BillingAddress != "" {
+		company.BillingAddress = input.BillingAddress
+	}
+	if input.BillingRegency != "" {
+		company.BillingRegency = input.BillingRegency
+	}
+	if input.BillingDistrict != "" {
+		company.BillingDistrict = input.BillingDistrict
+	}
+	return true
+}
+
+// CreateCompany godoc
+// @Summary      Create company
+// @Description  Buat company B2B. User yang login otomatis jadi owner; staff (users:write) bisa menentukan owner_user_id.
+// @Tags         Companies
+// @Accept       json
+// @Produce      json
+// @Param        input body CompanyInput true "Data company"
+// @Success      201 {object} models.Company
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      409 {object} utils.ErrorResponse
+// @Router       /companies [post]
+func CreateCompany(c *gin.Context) {
+	var input CompanyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "name is required", nil)
+		return
+	}
+
+	ownerID := currentUserID(c)
+	if input.OwnerUserID != "" && utils.CurrentPrincipal(c).Can(utils.PermUsersWrite) {
+		ownerID = utils.ParseUUID(input.OwnerUserID)
+	}
+	var owner models.User
+	if ownerID == uuid.Nil || config.DB.First(&owner, "id = ?", ownerID).Error != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid owner_user_id", nil)
+		return
+	}
+
+	company := models.Company{}
+	if !applyCompanyInput(c, &company, input) {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&company).Error; err != nil {
+			return err
+		}
+		member := models.CompanyMember{CompanyID: company.ID, UserID: owner.ID, Role: companyRoleOwner}
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+		member.User = &owner
+		company.Members = []models.CompanyMember{member}
+		return nil
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create company", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, "Company created", company)
+}
+
+// GetCompanies godoc
+// @Summary      List companies
+// @Description  Staff: daftar company dengan pencarian nama / NPWP dan pagination
+// @Tags         Companies
+// @Produce      json
+// @Param        q     query string false "Cari nama atau NPWP"
+// @Param        page  query int    false "Page number (default is 1)"
+// @Param        limit query int    false "Number of items per page (default is 10, max 100)"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /admin/companies [get]
+func GetCompanies(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	query := config.DB.Model(&models.Company{})
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		if npwp, err := utils.NormalizeNPWP(q); err == nil {
+			query = query.Where("companies.npwp = ?", npwp)
+		} else {
+			query = query.Where("companies.name ILIKE ?", "%"+utils.EscapeLike(q)+"%")
+		}
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to count companies", nil)
+		return
+	}
+
+	companies := []struct {
+		models.Company
+		MemberCount int64 `json:"member_count"`
+		OrderCount  int64 `json:"order_count"`
+	}{}
+	if err := query.
+		Select(`companies.*,
+			(SELECT COUNT(*) FROM company_members m WHERE m.company_id = companies.id) AS member_count,
+			(SELECT COUNT(*) FROM orders o WHERE o.company_id = companies.id AND o.deleted_at IS NULL) AS order_count`).
+		Order("companies.name").
+		Limit(limit).Offset((page - 1) * limit).
+		Scan(&companies).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get companies", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", gin.H{
+		"data":       companies,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+		"totalPages": int(math.Ceil(float64(total) / float64(limit))),
+	})
+}
+
+// GetMyCompanies godoc
+// @Summary      Get my companies
+// @Description  Company tempat user yang login menjadi anggota, beserta role & status-nya (invited = undangan yang belum diterima)
+// @Tags         Me
+// @Produce      json
+// @Success      200 {array} models.CompanyMember
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /me/companies [get]
+func GetMyCompanies(c *gin.Context) {
+	memberships := []models.CompanyMember{}
+	if err := config.DB.Preload("Company").
+		Where("user_id = ?", currentUserID(c)).
+		Order("created_at ASC").
+		Find(&memberships).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get companies", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", memberships)
+}
+
+// GetCompany godoc
+// @Summary      Get company
+// @Description  Detail company beserta anggotanya (anggota company atau staff)
+// @Tags         Companies
+// @Produce      json
+// @Param        id path string true "Company ID"
+// @Success      200 {object} models.Company
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /companies/{id} [get]
+func GetCompany(c *gin.Context) {
+	company, ok := loadCompany(c, utils.PermUsersRead, false)
+	if !ok {
+		return
+	}
+	config.DB.Preload("User").Where("company_id = ?", company.ID).Order("created_at ASC").Find(&company.Members)
+	for i := range company.Members {
+		// undangan yang belum diterima: data user belum boleh terlihat anggota lain
+		if company.Members[i].Status != companyMemberActive {
+			company.Members[i].User = nil
+		}
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", company)
+}
+
+// UpdateCompany godoc
+// @Summary      Update company
+// @Description  Owner company atau staff (users:write)
+// @Tags         Companies
+// @Accept       json
+// @Produce      json
+// @Param        id    path string true "Company ID"
+// @Param        input body CompanyInput true "Field yang diubah"
+// @Success      200 {object} models.Company
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      403 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /companies/{id} [patch]
+func UpdateCompany(c *gin.Context) {
+	var input CompanyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	company, ok := loadCompany(c, utils.PermUsersWrite, true)
+	if !ok {
+		return
+	}
+	if !applyCompanyInput(c, &company, input) {
+		return
+	}
+
+	if err := config.DB.Save(&company).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update company", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Company updated", company)
+}
+
+// GetCompanyOrders godoc
+// @Summary      Get company orders
+// @Description  Semua order company dari semua anggota (anggota company atau staff orders:read)
+// @Tags         Companies
+// @Produce      json
+// @Param        id path string true "Company ID"
+// @Success      200 {array} models.Order
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /companies/{id}/orders [get]
+func GetCompanyOrders(c *gin.Context) {
+	company, ok := loadCompany(c, utils.PermOrdersRead, false)
+	if !ok {
+		return
+	}
+
+	orders := []models.Order{}
+	if err := config.DB.Preload("User").Preload("Product").
+		Where("company_id = ?", company.ID).
+		Order("created_at DESC").
+		Find(&orders).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get orders", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", orders)
+}
+
+// GetCompanyNotifications godoc
+// @Summary      Get company notifications
+// @Description  Notifikasi semua order company (anggota company atau staff orders:read)
+// @Tags         Companies
+// @Produce      json
+// @Param        id path string true "Company ID"
+// @Success      200 {array} NotificationResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /companies/{id}/notifications [get]
+func GetCompanyNotifications(c *gin.Context) {
+	company, ok := loadCompany(c, utils.PermOrdersRead, false)
+	if !ok {
+		return
+	}
+
+	var notifications []models.Notification
+	if err := config.DB.
+		Preload("Order.Product").
+		Preload("Order.User").
+		Joins("JOIN orders ON orders.id = notifications.order_id AND orders.deleted_at IS NULL").
+		Where("orders.company_id = ?", company.ID).
+		Order("notifications.created_at DESC").
+		Find(&notifications).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get notifications", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", toNotificationResponses(notifications))
+}
+
+// CompanyMemberResponse data anggota yang dikembalikan saat menambah anggota (tanpa profil user)
+type CompanyMemberResponse struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	Role   string    `json:"role"`
+	Status string    `json:"status"`
+}
+
+// AddCompanyMember godoc
+// @Summary      Add company member
+// @Description  Undang user terdaftar sebagai anggota. Owner company hanya bisa mengundang lewat user_id dan undangan harus
+// @Description  diterima user lewat /me/companies/{id}/accept. Staff (users:write) bisa menambah lewat user_id atau nomor HP dan langsung aktif.
+// @Tags         Companies
+// @Accept       json
+// @Produce      json
+// @Param        id    path string true "Company ID"
+// @Param        input body object{user_id=string,phone=string,role=string} true "role: owner | purchaser"
+// @Success      201 {object} CompanyMemberResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      409 {object} utils.ErrorResponse
+// @Router       /companies/{id}/members [post]
+func AddCompanyMember(c *gin.Context) {
+	var input struct {
+		UserID string `json:"user_id"`
+		Phone  string `json:"phone"`
+		Role   string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+	if input.Role == "" {
+		input.Role = companyRolePurchaser
+	}
+	if !validCompanyRole(input.Role) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "role must be owner or purchaser", nil)
+		return
+	}
+
+	company, ok := loadCompany(c, utils.PermUsersWrite, true)
+	if !ok {
+		return
+	}
+	isStaff := utils.CurrentPrincipal(c).Can(utils.PermUsersWrite)
+
+	var user models.User
+	var err error
+	switch {
+	case input.UserID != "":
+		err = config.DB.First(&user, "id = ?", utils.ParseUUID(input.UserID)).Error
+	case input.Phone != "" && isStaff:
+		err = config.DB.First(&user, "phone = ?", utils.NormalizePhoneOrRaw(input.Phone)).Error
+	case input.Phone != "":
+		utils.SendErrorResponse(c, http.StatusForbidden, "Only staff can add members by phone", nil)
+		return
+	default:
+		utils.SendErrorResponse(c, http.StatusBadRequest, "user_id is required", nil)
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	var count int64
+	if err := config.DB.Model(&models.CompanyMember{}).
+		Where("company_id = ? AND user_id = ?", company.ID, user.ID).
+		Count(&count).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to add member", nil)
+		return
+	}
+	if count > 0 {
+		utils.SendErrorResponse(c, http.StatusConflict, "User is already a member or invited", nil)
+		return
+	}
+
+	member := models.CompanyMember{CompanyID: company.ID, UserID: user.ID, Role: input.Role, Status: companyMemberInvited}
+	if isStaff {
+		member.Status = companyMemberActive
+	}
+	if err := config.DB.Create(&member).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to add member", nil)
+		return
+	}
+
+	message := "Member added"
+	if member.Status == companyMemberInvited {
+		message = "Invitation sent"
+	}
+	utils.SendSuccessResponse(c, http.StatusCreated, message, CompanyMemberResponse{
+		ID:     member.ID,
+		UserID: member.UserID,
+		Role:   member.Role,
+		Status: member.Status,
+	})
+}
+
+// AcceptCompanyInvite godoc
+// @Summary      Accept company invitation
+// @Description  User menerima undangan menjadi anggota company
+// @Tags         Me
+// @Produce      json
+// @Param        id path string true "Company ID"
+// @Success      200 {object} models.CompanyMember
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /me/companies/{id}/accept [post]
+func AcceptCompanyInvite(c *gin.Context) {
+	var member models.CompanyMember
+	if err := config.DB.First(&member, "company_id = ? AND user_id = ? AND status = ?",
+		utils.ParseUUID(c.Param("id")), currentUserID(c), companyMemberInvited).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Invitation not found", nil)
+		return
+	}
+	if err := config.DB.Model(&member).Update("status", companyMemberActive).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to accept invitation", nil)
+		return
+	}
+	member.Status = companyMemberActive
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Invitation accepted", member)
+}
+
+// DeclineCompanyInvite godoc
+// @Summary      Decline company invitation
+// @Tags         Me
+// @Produce      json
+// @Param        id path string true "Company ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /me/companies/{id}/decline [post]
+func DeclineCompanyInvite(c *gin.Context) {
+	res := config.DB.Where("company_id = ? AND user_id = ? AND status = ?",
+		utils.ParseUUID(c.Param("id")), currentUserID(c), companyMemberInvited).
+		Delete(&models.CompanyMember{})
+	if res.Error != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to decline invitation", nil)
+		return
+	}
+	if res.RowsAffected == 0 {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Invitation not found", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Invitation declined", nil)
+}
+
+// UpdateCompanyMember godoc
+// @Summary      Change company member role
+// @Tags         Companies
+// @Accept       json
+// @Produce      json
+// @Param        id      path string true "Company ID"
+// @Param        user_id path string true "User ID anggota"
+// @Param        input   body object{role=string} true "role: owner | purchaser"
+// @Success      200 {object} models.CompanyMember
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /companies/{id}/members/{user_id} [patch]
+func UpdateCompanyMember(c *gin.Context) {
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || !validCompanyRole(input.Role) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "role must be owner or purchaser", nil)
+		return
+	}
+
+	company, ok := loadCompany(c, utils.PermUsersWrite, true)
+	if !ok {
+		return
+	}
+
+	var member models.CompanyMember
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&member, "company_id = ? AND user_id = ?", company.ID, utils.ParseUUID(c.Param("user_id"))).Error; err != nil {
+			return err
+		}
+		if member.Role == companyRoleOwner && input.Role != companyRoleOwner {
+			if err := ensureAnotherOwner(tx, member); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&member).Update("role", input.Role).Error; err != nil {
+			return err
+		}
+		member.Role = input.Role
+		return nil
+	})
+	if !companyMemberResult(c, err) {
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Member updated", member)
+}
+
+// RemoveCompanyMember godoc
+// @Summary      Remove company member
+// @Description  Owner / staff (users:write) menghapus anggota, atau anggota keluar sendiri. Owner terakhir tidak bisa dihapus.
+// @Tags         Companies
+// @Produce      json
+// @Param        id      path string true "Company ID"
+// @Param        user_id path string true "User ID anggota"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /companies/{id}/members/{user_id} [delete]
+func RemoveCompanyMember(c *gin.Context) {
+	userID := utils.ParseUUID(c.Param("user_id"))
+	leaving := userID != uuid.Nil && userID == currentUserID(c)
+
+	company, ok := loadCompany(c, utils.PermUsersWrite, !leaving)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var member models.CompanyMember
+		if err := tx.First(&member, "company_id = ? AND user_id = ?", company.ID, userID).Error; err != nil {
+			return err
+		}
+		if member.Role == companyRoleOwner {
+			if err := ensureAnotherOwner(tx, member); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&member).Error
+	})
+	if !companyMemberResult(c, err) {
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Member removed", nil)
+}
+
+var errLastCompanyOwner = errors.New("company must keep at least one owner")
+
+// ensureAnotherOwner company harus selalu punya minimal 1 owner aktif.
+// Baris owner dikunci FOR UPDATE supaya 2 owner yang saling menurunkan role
+// bersamaan tidak membuat company tanpa owner.
+func ensureAnotherOwner(tx *gorm.DB, member models.CompanyMember) error {
+	var owners []models.CompanyMember
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("company_id = ? AND role = ? AND status = ?", member.CompanyID, companyRoleOwner, companyMemberActive).
+		Find(&owners).Error; err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		if owner.ID != member.ID {
+			return nil
+		}
+	}
+	return errLastCompanyOwner
+}
+
+func companyMemberResult(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "Member not found", nil)
+	case errors.Is(err, errLastCompanyOwner):
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Company must keep at least one owner", nil)
+	default:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update member", nil)
+	}
+	return false
+}
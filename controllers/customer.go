@@ -0,0 +1,423 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CRM customer: tag dari admin, catatan internal staff dan ringkasan order.
+
+const maxCustomerNoteLength = 5000
+
+var tagColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// nama tag disimpan huruf kecil supaya "Kontraktor" dan "kontraktor" sama
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// splitQueryList memecah "a,b, c" menjadi daftar nama tag
+func splitQueryList(value string) []string {
+	var list []string
+	for _, part := range strings.Split(value, ",") {
+		if name := normalizeTagName(part); name != "" {
+			list = append(list, name)
+		}
+	}
+	return list
+}
+
+// attachUserTags mengisi tag untuk baris daftar user (Scan tidak bisa preload)
+func attachUserTags(items []UserListItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
+	var tagged []models.User
+	if err := config.DB.Select("id").Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name")
+	}).Find(&tagged, "id IN ?", ids).Error; err != nil {
+		return err
+	}
+	tags := map[uuid.UUID][]models.CustomerTag{}
+	for _, u := range tagged {
+		tags[u.ID] = u.Tags
+	}
+	for i := range items {
+		items[i].Tags = tags[items[i].ID]
+	}
+	return nil
+}
+
+// customerMetrics ringkasan order 1 customer
+func customerMetrics(userID uuid.UUID) (CustomerMetrics, error) {
+	var metrics CustomerMetrics
+	err := config.DB.Model(&models.Order{}).
+		Select(`COUNT(*) AS order_count, COALESCE(SUM(quantity), 0) AS total_quantity,
+			MIN(created_at) AS first_order_at, MAX(created_at) AS last_order_at`).
+		Where("user_id = ?", userID).
+		Scan(&metrics).Error
+	return metrics, err
+}
+
+// GetCustomerTags godoc
+// @Summary      List customer tags
+// @Description  Semua tag CRM beserta jumlah customer yang memakainya
+// @Tags         CRM
+// @Produce      json
+// @Success      200 {array} models.CustomerTag
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /admin/tags [get]
+func GetCustomerTags(c *gin.Context) {
+	var tags []struct {
+		models.CustomerTag
+		UserCount int64 `json:"user_count"`
+	}
+	if err := config.DB.Model(&models.CustomerTag{}).
+		Select("customer_tags.*, COUNT(ut.user_id) AS user_count").
+		Joins("LEFT JOIN user_tags ut ON ut.customer_tag_id = customer_tags.id").
+		Group("customer_tags.id").
+		Order("customer_tags.name").
+		Scan(&tags).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get tags", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", tags)
+}
+
+// CustomerTagInput body create / update tag
+type CustomerTagInput struct {
+	Name        string `json:"name" example:"kontraktor"`
+	Color       string `json:"color" example:"#1e88e5"`
+	Description string `json:"description" example:"Kontraktor / pemborong, order rutin"`
+}
+
+// CreateCustomerTag godoc
+// @Summary      Create customer tag
+// @Tags         CRM
+// @Accept       json
+// @Produce      json
+// @Param        input body CustomerTagInput true "Data tag"
+// @Success      201 {object} models.CustomerTag
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      409 {object} utils.ErrorResponse
+// @Router       /admin/tags [post]
+func CreateCustomerTag(c *gin.Context) {
+	var input CustomerTagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	tag := models.CustomerTag{}
+	if !applyCustomerTagInput(c, &tag, input) {
+		return
+	}
+	if tag.Name == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "name is required", nil)
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.CustomerTag{}).Where("name = ?", tag.Name).Count(&count)
+	if count > 0 {
+		utils.SendErrorResponse(c, http.StatusConflict, "Tag already exists", nil)
+		return
+	}
+
+	if err := config.DB.Create(&tag).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create tag", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, "Tag created", tag)
+}
+
+// UpdateCustomerTag godoc
+// @Summary      Update customer tag
+// @Tags         CRM
+// @Accept       json
+// @Produce      json
+// @Param        id    path string true "Tag ID"
+// @Param        input body CustomerTagInput true "Field yang diubah"
+// @Success      200 {object} models.CustomerTag
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      409 {object} utils.ErrorResponse
+// @Router       /admin/tags/{id} [patch]
+func UpdateCustomerTag(c *gin.Context) {
+	var input CustomerTagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	var tag models.CustomerTag
+	if err := config.DB.First(&tag, "id = ?", utils.ParseUUID(c.Param("id"))).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Tag not found", nil)
+		return
+	}
+	if !applyCustomerTagInput(c, &tag, input) {
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.CustomerTag{}).Where("name = ? AND id <> ?", tag.Name, tag.ID).Count(&count)
+	if count > 0 {
+		utils.SendErrorResponse(c, http.StatusConflict, "Tag already exists", nil)
+		return
+	}
+
+	if err := config.DB.Save(&tag).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update tag", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Tag updated", tag)
+}
+
+func applyCustomerTagInput(c *gin.Context, tag *models.CustomerTag, input CustomerTagInput) bool {
+	if name := normalizeTagName(input.Name); name != "" {
+		if strings.Contains(name, ",") {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Tag name must not contain commas", nil)
+			return false
+		}
+		tag.Name = name
+	}
+	if input.Color != "" {
+		if !tagColorPattern.MatchString(input.Color) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "color must be a hex color like #1e88e5", nil)
+			return false
+		}
+		tag.Color = strings.ToLower(input.Color)
+	}
+	if input.Description != "" {
+		tag.Description = input.Description
+	}
+	return true
+}
+
+// DeleteCustomerTag godoc
+// @Summary      Delete customer tag
+// @Description  Hapus tag dan lepas dari semua customer
+// @Tags         CRM
+// @Produce      json
+// @Param        id path string true "Tag ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/tags/{id} [delete]
+func DeleteCustomerTag(c *gin.Context) {
+	var tag models.CustomerTag
+	if err := config.DB.First(&tag, "id = ?", utils.ParseUUID(c.Param("id"))).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Tag not found", nil)
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM user_tags WHERE customer_tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to delete tag", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Tag deleted", nil)
+}
+
+// GetCustomerCRM godoc
+// @Summary      Get customer CRM profile
+// @Description  Data customer beserta tag, ringkasan order dan catatan internal (terbaru dulu)
+// @Tags         CRM
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/users/{id}/crm [get]
+func GetCustomerCRM(c *gin.Context) {
+	var user models.User
+	if err := config.DB.Preload("Tags").First(&user, "id = ?", utils.ParseUUID(c.Param("id"))).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	metrics, err := customerMetrics(user.ID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get metrics", nil)
+		return
+	}
+
+	notes := []models.CustomerNote{}
+	if err := config.DB.Preload("Author").
+		Where("user_id = ?", user.ID).
+		Order("created_at DESC").
+		Find(&notes).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get notes", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", gin.H{
+		"user":    user,
+		"metrics": metrics,
+		"notes":   notes,
+	})
+}
+
+// SetCustomerTags godoc
+// @Summary      Set customer tags
+// @Description  Ganti semua tag customer dengan daftar nama tag yang dikirim (kosong = lepas semua)
+// @Tags         CRM
+// @Accept       json
+// @Produce      json
+// @Param        id    path string true "User ID"
+// @Param        input body object{tags=[]string} true "Nama tag"
+// @Success      200 {object} models.User
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/users/{id}/tags [put]
+func SetCustomerTags(c *gin.Context) {
+	var input struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", utils.ParseUUID(c.Param("id"))).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	names := []string{}
+	for _, name := range input.Tags {
+		if name = normalizeTagName(name); name != "" && !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	tags := []models.CustomerTag{}
+	if len(names) > 0 {
+		if err := config.DB.Where("name IN ?", names).Order("name").Find(&tags).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch tags", nil)
+			return
+		}
+	}
+	if len(tags) != len(names) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Unknown tag in list", nil)
+		return
+	}
+
+	if err := config.DB.Model(&user).Association("Tags").Replace(tags); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update tags", nil)
+		return
+	}
+	user.Tags = tags
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Tags updated", user)
+}
+
+// CreateCustomerNote godoc
+// @Summary      Add internal note
+// @Description  Tambah catatan internal tentang customer, tercatat atas nama staff yang login
+// @Tags         CRM
+// @Accept       json
+// @Produce      json
+// @Param        id    path string true "User ID"
+// @Param        input body object{body=string} true "Isi catatan"
+// @Success      201 {object} models.CustomerNote
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/users/{id}/notes [post]
+func CreateCustomerNote(c *gin.Context) {
+	var input struct {
+		Body string `json:"body" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Body) == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "body is required", nil)
+		return
+	}
+	if len([]rune(input.Body)) > maxCustomerNoteLength {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Note is too long", nil)
+		return
+	}
+
+	// catatan harus punya penulis, jadi API key tidak bisa menulis catatan
+	authorID := currentUserID(c)
+	if authorID == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusForbidden, "Notes must be written by a staff user", nil)
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", utils.ParseUUID(c.Param("id"))).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	note := models.CustomerNote{
+		UserID:   user.ID,
+		AuthorID: authorID,
+		Body:     strings.TrimSpace(input.Body),
+	}
+	if err := config.DB.Create(&note).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create note", nil)
+		return
+	}
+	config.DB.First(&note.Author, "id = ?", authorID)
+
+	utils.SendSuccessResponse(c, http.StatusCreated, "Note created", note)
+}
+
+// DeleteCustomerNote godoc
+// @Summary      Delete internal note
+// @Description  Catatan hanya bisa dihapus penulisnya atau staff dengan users:write
+// @Tags         CRM
+// @Produce      json
+// @Param        id      path string true "User ID"
+// @Param        note_id path string true "Note ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      403 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/users/{id}/notes/{note_id} [delete]
+func DeleteCustomerNote(c *gin.Context) {
+	var note models.CustomerNote
+	err := config.DB.First(&note, "id = ? AND user_id = ?",
+		utils.ParseUUID(c.Param("note_id")), utils.ParseUUID(c.Param("id"))).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Note not found", nil)
+		return
+	} else if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get note", nil)
+		return
+	}
+
+	if note.AuthorID != currentUserID(c) && !utils.CurrentPrincipal(c).Can(utils.PermUsersWrite) {
+		utils.SendErrorResponse(c, http.StatusForbidden, "Only the author can delete this note", nil)
+		return
+	}
+
+	if err := config.DB.Delete(&note).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to delete note", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Note deleted", nil)
+}
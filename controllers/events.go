@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateEventTicket godoc
+// @Summary      Create SSE / WebSocket connect ticket
+// @Description  Ticket sekali pakai (berlaku 30 detik) untuk membuka /events?ticket=... (atau WebSocket) dari browser yang tidak bisa kirim header Authorization
+// @Tags         Events
+// @Produce      json
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      401 {object} utils.ErrorResponse
+// @Router       /events/ticket [post]
+func CreateEventTicket(c *gin.Context) {
+	claims := currentClaims(c)
+	if claims == nil {
+		// API key tidak bisa membuka stream notifikasi
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "User token required", nil)
+		return
+	}
+
+	ticket, err := utils.IssueConnectTicket(claims)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create ticket", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", gin.H{
+		"ticket":     ticket,
+		"expires_in": int(utils.ConnectTicketTTL.Seconds()),
+	})
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// token impersonation sengaja singkat dan tanpa refresh token
+const impersonationTTL = 10 * time.Minute
+
+// StartImpersonation godoc
+// @Summary      Impersonate a customer
+// @Description  Admin: buat token singkat sebagai customer untuk bantu support. Token membawa claim act (admin aslinya),
+// @Description  setiap request dicatat di audit log, dan tidak bisa dipakai untuk ganti password / data akun.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "User ID customer"
+// @Param        input body object{reason=string} true "Alasan (misal nomor tiket support)"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      403 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/users/{id}/impersonate [post]
+func StartImpersonation(c *gin.Context) {
+	var input struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "reason is required", nil)
+		return
+	}
+
+	claims := currentClaims(c)
+	if claims == nil || claims.Act != nil {
+		utils.SendErrorResponse(c, http.StatusForbidden, "Impersonation requires your own staff token", nil)
+		return
+	}
+
+	targetID := utils.ParseUUID(c.Param("id"))
+	if targetID == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	var account models.UserAccount
+	if err := config.DB.Preload("User").Preload("Role").First(&account, "user_id = ?", targetID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Account not found", nil)
+		return
+	}
+	// hanya customer; staff tidak boleh dipakai identitasnya
+	if account.Role.IsStaff || account.DeactivatedAt != nil {
+		utils.SendErrorResponse(c, http.StatusForbidden, "Only active customer accounts can be impersonated", nil)
+		return
+	}
+
+	// sid ikut sesi admin: logout / revoke sesi admin ikut mematikan token ini
+	impClaims := accountClaims(account, claims.SessionID)
+	impClaims.Act = &utils.Actor{Sub: claims.UserID, Role: c.GetString("role")}
+	token, err := utils.GenerateAccessTokenWithTTL(impClaims, impersonationTTL)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to generate token", nil)
+		return
+	}
+
+	entry := utils.AuditFromContext(c, "impersonation.start")
+	entry.SubjectID = account.UserID
+	entry.StatusCode = http.StatusOK
+	utils.WriteAuditLog(entry, gin.H{"reason": input.Reason, "jti": impClaims.ID, "expires_at": impClaims.ExpiresAt.Time})
+
+	log.Printf("🎭 Impersonation started: actor=%s subject=%s reason=%q", claims.UserID, account.UserID, input.Reason)
+	utils.SendSuccessResponse(c, http.StatusOK, "Impersonation token created", gin.H{
+		"token":      token,
+		"expires_in": int(impersonationTTL.Seconds()),
+		"user":       account.User,
+	})
+}
+
+// GetAuditLogs godoc
+// @Summary      List audit logs
+// @Tags         Admin
+// @Produce      json
+// @Param        action     query string false "Filter action (contoh: impersonation.start)"
+// @Param        actor_id   query string false "Filter staff pelaku"
+// @Param        subject_id query string false "Filter user yang terdampak"
+// @Param        limit      query int    false "Jumlah data (default 100, maks 500)"
+// @Success      200 {array} models.AuditLog
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /admin/audit-logs [get]
+func GetAuditLogs(c *gin.Context) {
+	query := config.DB.Model(&models.AuditLog{})
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if actorID := utils.ParseUUID(c.Query("actor_id")); actorID != uuid.Nil {
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if subjectID := utils.ParseUUID(c.Query("subject_id")); subjectID != uuid.Nil {
+		query = query.Where("subject_id = ?", subjectID)
+	}
+
+	limit := utils.StringToInt(c.DefaultQuery("limit", "100"))
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("created_at DESC").Limit(limit).Find(&logs).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch audit logs", nil)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", logs)
+}
@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS godoc
+// @Summary      JSON Web Key Set
+// @Description  Public key untuk verifikasi access token oleh service lain (hanya key RS256 / EdDSA)
+// @Tags         Auth
+// @Produce      json
+// @Success      200 {object} map[string]interface{}
+// @Router       /.well-known/jwks.json [get]
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": utils.PublicJWKs()})
+}
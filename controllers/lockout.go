@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetLoginLockouts godoc
+// @Summary      List login lockouts
+// @Description  Daftar nomor HP / IP yang sedang dikunci karena terlalu banyak gagal login
+// @Tags         Admin
+// @Produce      json
+// @Success      200 {array} utils.LoginLockout
+// @Router       /admin/lockouts [get]
+func GetLoginLockouts(c *gin.Context) {
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", utils.ListLoginLockouts())
+}
+
+// ClearLoginLockout godoc
+// @Summary      Clear login lockout
+// @Description  Buka kunci login dan reset counter gagal untuk nomor HP atau IP
+// @Tags         Admin
+// @Produce      json
+// @Param        kind  path string true "phone atau ip"
+// @Param        value path string true "Nomor HP / alamat IP"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Router       /admin/lockouts/{kind}/{value} [delete]
+func ClearLoginLockout(c *gin.Context) {
+	kind := c.Param("kind")
+	value := c.Param("value")
+
+	if kind != utils.LockoutKindPhone && kind != utils.LockoutKindIP {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "kind must be phone or ip", nil)
+		return
+	}
+	if value == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "value is required", nil)
+		return
+	}
+
+	utils.ClearLoginLockout(kind, value)
+	utils.SendSuccessResponse(c, http.StatusOK, "Lockout cleared", gin.H{"kind": kind, "value": value})
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Endpoint /me selalu memakai identitas dari token, bukan dari URL / query.
+
+// currentUserID mengambil user_id dari token yang sudah divalidasi AuthMiddleware
+func currentUserID(c *gin.Context) uuid.UUID {
+	return utils.ParseUUID(c.GetString("user_id"))
+}
+
+// GetMe godoc
+// @Summary      Get current user
+// @Description  Data user yang sedang login beserta role akunnya
+// @Tags         Me
+// @Produce      json
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /me [get]
+func GetMe(c *gin.Context) {
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", currentUserID(c)).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	response := gin.H{
+		"user": user,
+		"role": c.GetString("role"),
+	}
+	if actor := c.GetString("impersonator_id"); actor != "" {
+		response["impersonated_by"] = actor
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", response)
+}
+
+// GetMyProfile godoc
+// @Summary      Get my profile
+// @Tags         Me
+// @Produce      json
+// @Success      200 {object} models.User
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /me/profile [get]
+func GetMyProfile(c *gin.Context) {
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", currentUserID(c)).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", user)
+}
+
+// UpdateMyProfile godoc
+// @Summary      Update my profile
+// @Description  Update profil user yang sedang login (form-data sama dengan PATCH /users/{id}).
+// @Description  Nomor HP tidak bisa diganti di sini karena dipakai untuk login; phone yang berbeda dari nomor sekarang ditolak.
+// @Tags         Me
+// @Accept       multipart/form-data
+// @Produce      json
+// @Success      200 {object} models.User
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /me/profile [patch]
+func UpdateMyProfile(c *gin.Context) {
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", currentUserID(c)).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	saveUserProfile(c, &user, false)
+}
+
+// GetMyOrders godoc
+// @Summary      Get my orders
+// @Description  Semua order milik user yang sedang login. Tambahkan ?history=true untuk histori status.
+// @Tags         Me
+// @Produce      json
+// @Param        history query bool false "Tampilkan histori status"
+// @Success      200 {array} models.Order
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /me/orders [get]
+func GetMyOrders(c *gin.Context) {
+	if c.Query("history") == "true" {
+		response, err := orderHistoryByUser(currentUserID(c))
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get order history", nil)
+			return
+		}
+		utils.SendSuccessResponse(c, http.StatusOK, "Success", response)
+		return
+	}
+
+	orders, err := findOrdersByUser(currentUserID(c))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get orders", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", orders)
+}
+
+// GetMyNotifications godoc
+// @Summary      Get my notifications
+// @Tags         Me
+// @Produce      json
+// @Success      200 {array} NotificationResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /me/notifications [get]
+func GetMyNotifications(c *gin.Context) {
+	response, err := userNotifications(currentUserID(c))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get notifications", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", response)
+}
@@ -30,10 +30,14 @@ type OrderInput struct {
 		Lat      float64 `json:"lat" example:""`
 	} `json:"user"`
 	ProductID   string `json:"product_id" example:"4cd64505-f56f-421b-9243-e6bc9cbbaa7b"`
+	VariantID   string `json:"variant_id" example:"4cd64505-f56f-421b-9243-e6bc9cbbaa7b"` // wajib kalau produk punya varian aktif
+	QuoteID     string `json:"quote_id" example:"4cd64505-f56f-421b-9243-e6bc9cbbaa7b"`   // dari POST /products/:id/quote; quantity, varian & harga diambil dari quote
 	CompanyName string `json:"company_name" example:"PT. ABC"`
+	CompanyID   string `json:"company_id" example:"4cd64505-f56f-421b-9243-e6bc9cbbaa7b"` // order atas nama company, wajib login sebagai anggotanya
 	Priority    string `json:"priority" example:"normal"`
 	Details     string `json:"details" example:"Pesanan baru dari Budi"`
 	Address     string `json:"address" example:"Jl. Mawar No. 123"`
+	AddressID   string `json:"address_id" example:"4cd64505-f56f-421b-9243-e6bc9cbbaa7b"` // dari /me/addresses (wajib login), diutamakan dibanding address
 	Quantity    int    `json:"quantity" example:"2"`
 }
 
@@ -46,6 +50,8 @@ type OrderInput struct {
 // @Param        order body OrderInput true "Order Input"
 // @Success      201 {object} utils.SuccessResponse
 // @Failure      400 {object} utils.ErrorResponse
+// @Failure      401 {object} utils.ErrorResponse
+// @Failure      403 {object} utils.ErrorResponse
 // @Failure      500 {object} utils.ErrorResponse
 // @Router       /orders [post]
 func CreateOrderAndNotify(c *gin.Context) {
@@ -54,8 +60,77 @@ func CreateOrderAndNotify(c *gin.Context) {
 		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
 		return
 	}
+	if !normalizeInputPhone(c, &input.User.Phone) {
+		return
+	}
+
+	// ✅ Validasi product_id + minimum order (harga terjadwal yang jatuh tempo diterapkan dulu)
+	productUUID := utils.ParseUUID(input.ProductID)
+	if productUUID == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+	applyDuePrices(productUUID)
+	var product models.Product
+	if err := config.DB.First(&product, "id = ?", productUUID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid product ID", nil)
+		return
+	}
+
+	// ✅ Order ukuran custom dari quote: jumlah, varian & harga ikut quote (minimum order sudah dihitung di rumus)
+	var quote *models.OrderQuote
+	var variant *models.ProductVariant
+	if input.QuoteID != "" {
+		quote = &models.OrderQuote{}
+		quoteID := utils.ParseUUID(input.QuoteID)
+		if quoteID == uuid.Nil || config.DB.First(quote, "id = ? AND product_id = ? AND order_id IS NULL AND expires_at > ?",
+			quoteID, product.ID, time.Now()).Error != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid or expired quote", nil)
+			return
+		}
+		input.Quantity = quote.Quantity
+		if quote.VariantID != nil {
+			variant = &models.ProductVariant{}
+			if config.DB.Unscoped().First(variant, "id = ?", *quote.VariantID).Error != nil {
+				variant = nil
+			}
+		}
+	} else {
+		if input.Quantity < product.MinOrderQty {
+			utils.SendErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Minimum order quantity is %d %s", product.MinOrderQty, product.PriceUnit), nil)
+			return
+		}
 
+		// ✅ Varian: wajib dipilih kalau produk punya varian aktif, harus milik produk ini
+		var ok bool
+		if variant, ok = resolveOrderVariant(c, product, input.VariantID, nil); !ok {
+			return
+		}
+	}
+
+	// ✅ Order atas nama company: wajib login dan user yang login harus anggota company.
+	// Order dicatat atas nama user yang login, bukan user dari body.
 	var user models.User
+	var company *models.Company
+	if input.CompanyID != "" {
+		principalID := utils.ParseUUID(c.GetString("user_id"))
+		if principalID == uuid.Nil || config.DB.First(&user, "id = ?", principalID).Error != nil {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "Login required to order on behalf of a company", nil)
+			return
+		}
+		company = &models.Company{}
+		companyID := utils.ParseUUID(input.CompanyID)
+		if companyID == uuid.Nil || config.DB.First(company, "id = ?", companyID).Error != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid company ID", nil)
+			return
+		}
+		if companyRole(company.ID, user.ID) == "" {
+			utils.SendErrorResponse(c, http.StatusForbidden, "User is not a member of this company", nil)
+			return
+		}
+		input.User.ID = user.ID.String()
+	}
+
 	var err error
 
 	// ✅ 1. Cek berdasarkan UserID jika ada
@@ -93,13 +168,6 @@ func CreateOrderAndNotify(c *gin.Context) {
 		fmt.Println("User ditemukan, pakai data existing:", user.ID)
 	}
 
-	// ✅ Validasi product_id
-	productUUID := utils.ParseUUID(input.ProductID)
-	if productUUID == uuid.Nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid product ID", nil)
-		return
-	}
-
 	// ✅ Buat order
 	order := models.Order{
 		UserID:      user.ID, // Ambil dari user existing atau baru
@@ -109,9 +177,74 @@ func CreateOrderAndNotify(c *gin.Context) {
 		Details:     input.Details,
 		Address:     input.Address,
 		Quantity:    input.Quantity,
+		UnitPrice:   product.Price,
+		PriceUnit:   product.PriceUnit,
 		Status:      "Menunggu konfirmasi",
 	}
-	if err := config.DB.Create(&order).Error; err != nil {
+	if variant != nil {
+		order.VariantID = &variant.ID
+		order.VariantSKU = variant.SKU
+		order.VariantName = variant.Name
+		if product.Price > 0 {
+			order.UnitPrice = max(product.Price+variant.PriceModifier, 0)
+		}
+	}
+	order.Subtotal = order.UnitPrice * int64(order.Quantity)
+	if quote != nil {
+		order.QuoteID = &quote.ID
+		order.UnitPrice = quote.UnitPrice
+		order.PriceUnit = utils.PriceUnitPiece
+		order.Subtotal = quote.Total
+	}
+
+	if company != nil {
+		order.CompanyID = &company.ID
+		order.CompanyName = company.Name
+	}
+
+	// ✅ Alamat dari buku alamat (harus milik user ini), isinya disalin ke order.
+	// Tanpa address_id dan tanpa alamat teks → pakai alamat default user.
+	// Hanya untuk user yang login sebagai pemilik data: guest yang mengisi nomor HP orang lain
+	// tidak boleh mendapat alamat tersimpan orang tersebut.
+	isOwner := c.GetString("user_id") != "" && c.GetString("user_id") == user.ID.String()
+	var address models.Address
+	if input.AddressID != "" && !isOwner {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Login required to use a saved address", nil)
+		return
+	}
+	if input.AddressID != "" {
+		addressID := utils.ParseUUID(input.AddressID)
+		if addressID == uuid.Nil || config.DB.First(&address, "id = ? AND user_id = ?", addressID, user.ID).Error != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid address ID", nil)
+			return
+		}
+		snapshotAddress(&order, address)
+	} else if input.Address == "" && isOwner {
+		if config.DB.First(&address, "user_id = ? AND is_default = ?", user.ID, true).Error == nil {
+			snapshotAddress(&order, address)
+		}
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+		if quote == nil {
+			return nil
+		}
+		// quote hanya boleh dipakai 1 order
+		res := tx.Model(&models.OrderQuote{}).Where("id = ? AND order_id IS NULL", quote.ID).Update("order_id", order.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errQuoteUsed
+		}
+		return nil
+	})
+	if errors.Is(err, errQuoteUsed) {
+		utils.SendErrorResponse(c, http.StatusConflict, "Quote has already been used", nil)
+		return
+	} else if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal menyimpan order", nil)
 		return
 	}
@@ -137,9 +270,14 @@ func CreateOrderAndNotify(c *gin.Context) {
 	notifJson, _ := json.Marshal(notif)
 	sse.BroadcastToRole("admin", string(notifJson))
 
+	// data user lengkap hanya untuk pemiliknya, guest cukup ID-nya
+	var userData interface{} = gin.H{"id": user.ID}
+	if isOwner {
+		userData = user
+	}
 	utils.SendSuccessResponse(c, http.StatusCreated, "Success", map[string]interface{}{
 		"order": order,
-		"user":  user,
+		"user":  userData,
 	})
 }
 
@@ -234,9 +372,16 @@ func UpdateOrderStatusAndNotify(c *gin.Context) {
 		return
 	}
 
-	// Kirim notifikasi via SSE
+	// Kirim notifikasi via SSE (order company juga ke semua anggotanya)
 	notifJson, _ := json.Marshal(notif)
 	sse.BroadcastToUser(order.User.ID.String(), string(notifJson))
+	if order.CompanyID != nil {
+		for _, memberID := range companyMemberIDs(*order.CompanyID) {
+			if memberID != order.UserID {
+				sse.BroadcastToUser(memberID.String(), string(notifJson))
+			}
+		}
+	}
 	log.Println("order ID:", order.User.ID.String())
 
 	utils.SendSuccessResponse(c, http.StatusOK, "Status order berhasil diperbarui", gin.H{
@@ -258,61 +403,12 @@ func GetNotification(c *gin.Context) {
 		return
 	}
 
-	var notifications []models.Notification
-
-	// Ambil notifikasi + relasi Order & Product
-	if err := config.DB.
-		Preload("Order.Product").
-		Preload("Order.User").
-		Where("user_id = ?", userID).
-		Order("created_at DESC").
-		Find(&notifications).Error; err != nil {
+	response, err := userNotifications(userID)
+	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get notifications", nil)
 		return
 	}
 
-	// Transform ke response agar tidak kirim semua field (misal hide DeletedAt)
-	type NotificationResponse struct {
-		ID        uuid.UUID `json:"id"`
-		Message   string    `json:"message"`
-		Read      bool      `json:"read"`
-		CreatedAt time.Time `json:"created_at"`
-		Order     struct {
-			ID        uuid.UUID `json:"id"`
-			OrderCode string    `json:"order_code"`
-			Status    string    `json:"status"`
-			Quantity  int       `json:"quantity"`
-			Company   string    `json:"company_name"`
-			Product   string    `json:"product_name"`
-			UserName  string    `json:"user_name"`
-			UserPhone string    `json:"user_phone"`
-			Detail    string    `json:"detail"`
-			Address   string    `json:"address"`
-		} `json:"order"`
-	}
-
-	var response []NotificationResponse
-	for _, n := range notifications {
-		item := NotificationResponse{
-			ID:        n.ID,
-			Message:   n.Message,
-			Read:      n.Read,
-			CreatedAt: n.CreatedAt,
-		}
-		item.Order.ID = n.Order.ID
-		item.Order.OrderCode = n.Order.OrderCode
-		item.Order.Status = n.Order.Status
-		item.Order.Quantity = n.Order.Quantity
-		item.Order.Company = n.Order.CompanyName
-		item.Order.Product = n.Order.Product.Name // pastikan model Product punya field Name
-		item.Order.UserName = n.Order.User.Name
-		item.Order.UserPhone = n.Order.User.Phone
-		item.Order.Detail = n.Order.Details
-		item.Order.Address = n.Order.Address
-
-		response = append(response, item)
-	}
-
 	utils.SendSuccessResponse(c, http.StatusOK, "Success", response)
 }
 
@@ -332,48 +428,7 @@ func GetAdminNotifications(c *gin.Context) {
 	}
 
 	// Transform ke response
-	type NotificationResponse struct {
-		ID        uuid.UUID `json:"id"`
-		Message   string    `json:"message"`
-		Read      bool      `json:"read"`
-		CreatedAt time.Time `json:"created_at"`
-		Order     struct {
-			ID        uuid.UUID `json:"id"`
-			OrderCode string    `json:"order_code"`
-			Status    string    `json:"status"`
-			Quantity  int       `json:"quantity"`
-			Company   string    `json:"company_name"`
-			Product   string    `json:"product_name"`
-			UserName  string    `json:"user_name"`
-			UserPhone string    `json:"user_phone"`
-			Detail    string    `json:"detail"`
-			Address   string    `json:"address"`
-		} `json:"order"`
-	}
-
-	var response []NotificationResponse
-	for _, n := range notifications {
-		item := NotificationResponse{
-			ID:        n.ID,
-			Message:   n.Message,
-			Read:      n.Read,
-			CreatedAt: n.CreatedAt,
-		}
-		item.Order.ID = n.Order.ID
-		item.Order.OrderCode = n.Order.OrderCode
-		item.Order.Status = n.Order.Status
-		item.Order.Quantity = n.Order.Quantity
-		item.Order.Company = n.Order.CompanyName
-		item.Order.Product = n.Order.Product.Name
-		item.Order.UserName = n.Order.User.Name
-		item.Order.UserPhone = n.Order.User.Phone
-		item.Order.Detail = n.Order.Details
-		item.Order.Address = n.Order.Address
-
-		response = append(response, item)
-	}
-
-	utils.SendSuccessResponse(c, http.StatusOK, "Success", response)
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", toNotificationResponses(notifications))
 }
 
 func MarkNotificationAsRead(c *gin.Context) {
@@ -401,6 +456,12 @@ func MarkNotificationAsRead(c *gin.Context) {
 		return
 	}
 
+	// hanya pemilik notifikasi (atau admin/staff order) yang boleh menandai dibaca
+	if notif.UserID.String() != c.GetString("user_id") && !utils.CurrentPrincipal(c).Can(utils.PermOrdersRead) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Notification not found", nil)
+		return
+	}
+
 	// Update kolom read
 	notif.Read = true
 	if err := config.DB.Save(&notif).Error; err != nil {
@@ -415,3 +476,64 @@ func MarkNotificationAsRead(c *gin.Context) {
 		"message": notif.Message,
 	})
 }
+
+// NotificationResponse agar tidak kirim semua field (misal hide DeletedAt)
+type NotificationResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Message   string    `json:"message"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+	Order     struct {
+		ID        uuid.UUID  `json:"id"`
+		OrderCode string     `json:"order_code"`
+		Status    string     `json:"status"`
+		Quantity  int        `json:"quantity"`
+		CompanyID *uuid.UUID `json:"company_id"`
+		Company   string     `json:"company_name"`
+		Product   string     `json:"product_name"`
+		UserName  string     `json:"user_name"`
+		UserPhone string     `json:"user_phone"`
+		Detail    string     `json:"detail"`
+		Address   string     `json:"address"`
+	} `json:"order"`
+}
+
+func toNotificationResponses(notifications []models.Notification) []NotificationResponse {
+	response := []NotificationResponse{}
+	for _, n := range notifications {
+		item := NotificationResponse{
+			ID:        n.ID,
+			Message:   n.Message,
+			Read:      n.Read,
+			CreatedAt: n.CreatedAt,
+		}
+		item.Order.ID = n.Order.ID
+		item.Order.OrderCode = n.Order.OrderCode
+		item.Order.Status = n.Order.Status
+		item.Order.Quantity = n.Order.Quantity
+		item.Order.CompanyID = n.Order.CompanyID
+		item.Order.Company = n.Order.CompanyName
+		item.Order.Product = n.Order.Product.Name // pastikan model Product punya field Name
+		item.Order.UserName = n.Order.User.Name
+		item.Order.UserPhone = n.Order.User.Phone
+		item.Order.Detail = n.Order.Details
+		item.Order.Address = n.Order.Address
+
+		response = append(response, item)
+	}
+	return response
+}
+
+// userNotifications mengambil notifikasi milik user + relasi Order & Product
+func userNotifications(userID uuid.UUID) ([]NotificationResponse, error) {
+	var notifications []models.Notification
+	if err := config.DB.
+		Preload("Order.Product").
+		Preload("Order.User").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return toNotificationResponses(notifications), nil
+}
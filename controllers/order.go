@@ -131,7 +131,7 @@ func GetAllOrders(c *gin.Context) {
 // @Failure      500  {object}  utils.ErrorResponse
 // @Router       /orders/{id} [get]
 func GetOrderByID(c *gin.Context) {
-	idParam := c.Param("id")
+	idParam := c.Param("orderid")
 	id, err := uuid.Parse(idParam)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid order ID", nil)
@@ -139,7 +139,7 @@ func GetOrderByID(c *gin.Context) {
 	}
 
 	var order models.Order
-	err = config.DB.Preload("User").Preload("Product").First(&order, "id = ?", id).Error
+	err = config.DB.Preload("User").Preload("Product").Preload("Quote").First(&order, "id = ?", id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		utils.SendErrorResponse(c, http.StatusNotFound, "Order not found", nil)
 		return
@@ -148,6 +148,13 @@ func GetOrderByID(c *gin.Context) {
 		return
 	}
 
+	// customer hanya boleh lihat order miliknya sendiri (atau order company tempat dia jadi anggota)
+	if order.UserID.String() != c.GetString("user_id") && !utils.CurrentPrincipal(c).Can(utils.PermOrdersRead) &&
+		(order.CompanyID == nil || companyRole(*order.CompanyID, currentUserID(c)) == "") {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Order not found", nil)
+		return
+	}
+
 	utils.SendSuccessResponse(c, http.StatusOK, "Success", order)
 }
 
@@ -169,8 +176,7 @@ func GetOrdersByUserID(c *gin.Context) {
 		return
 	}
 
-	var orders []models.Order
-	err = config.DB.Preload("User").Preload("Product").Where("user_id = ?", userID).Find(&orders).Error
+	orders, err := findOrdersByUser(userID)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get orders", nil)
 		return
@@ -191,27 +197,46 @@ func GetOrderHistoryByUserID(c *gin.Context) {
 		return
 	}
 
+	response, err := orderHistoryByUser(userID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get order history", nil)
+		return
+	}
+
+	if len(response) == 0 {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Orders not found for user", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", response)
+}
+
+// findOrdersByUser mengambil semua order milik user dengan preload user dan product
+func findOrdersByUser(userID uuid.UUID) ([]models.Order, error) {
 	var orders []models.Order
-	err = config.DB.
+	err := config.DB.Preload("User").Preload("Product").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&orders).Error
+	return orders, err
+}
+
+// orderHistoryByUser menyusun histori status order milik user
+func orderHistoryByUser(userID uuid.UUID) ([]map[string]interface{}, error) {
+	var orders []models.Order
+	err := config.DB.
 		Preload("Product").
 		Preload("Updates", func(db *gorm.DB) *gorm.DB {
 			return db.Order("timestamp ASC") // histori status urut waktu
 		}).
 		Where("user_id = ?", userID).
 		Find(&orders).Error
-
 	if err != nil {
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get order history", nil)
-		return
-	}
-
-	if len(orders) == 0 {
-		utils.SendErrorResponse(c, http.StatusNotFound, "Orders not found for user", nil)
-		return
+		return nil, err
 	}
 
 	// Bentuk respons sesuai format yang kamu mau
-	var response []map[string]interface{}
+	response := []map[string]interface{}{}
 	for _, o := range orders {
 		orderData := map[string]interface{}{
 			"orderId":   o.OrderCode,
@@ -222,6 +247,5 @@ func GetOrderHistoryByUserID(c *gin.Context) {
 		}
 		response = append(response, orderData)
 	}
-
-	utils.SendSuccessResponse(c, http.StatusOK, "Success", response)
+	return response, nil
 }
@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/otp"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const otpPurposeLogin = "login"
+
+var (
+	errAccountDeactivated = errors.New("account deactivated")
+	errOTPInvalid         = errors.New("invalid or expired code")
+	errOTPTooManyAttempts = errors.New("too many otp attempts")
+)
+
+// RequestOTP godoc
+// @Summary      Request OTP code
+// @Description  Kirim kode OTP ke nomor HP yang terdaftar. Response selalu sama supaya nomor tidak bisa ditebak.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        input body object{phone=string} true "Nomor HP"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      429 {object} utils.ErrorResponse
+// @Router       /otp/request [post]
+func RequestOTP(c *gin.Context) {
+	var input struct {
+		Phone string `json:"phone" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+	input.Phone = utils.NormalizePhoneOrRaw(input.Phone)
+
+	// batasi frekuensi permintaan per nomor, sebelum cek nomor terdaftar
+	if !throttlePhoneMessage(c, input.Phone) {
+		return
+	}
+
+	// hanya kirim kalau nomor dikenal, tapi response tetap sama
+	var user models.User
+	if err := config.DB.Where("phone = ?", input.Phone).First(&user).Error; err != nil {
+		utils.SendSuccessResponse(c, http.StatusOK, "If the number is registered, an OTP has been sent", nil)
+		return
+	}
+
+	code, err := otp.GenerateCode()
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to generate OTP", nil)
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	// kode lama yang belum dipakai tidak berlaku lagi
+	if err := tx.Model(&models.OTPCode{}).
+		Where("phone = ? AND consumed_at IS NULL", input.Phone).
+		Update("consumed_at", time.Now()).Error; err != nil {
+		tx.Rollback()
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to save OTP", nil)
+		return
+	}
+
+	otpCode := models.OTPCode{
+		Phone:     input.Phone,
+		Purpose:   otpPurposeLogin,
+		CodeHash:  otp.HashCode(input.Phone, code),
+		ExpiresAt: time.Now().Add(otp.CodeTTL),
+	}
+	if err := tx.Create(&otpCode).Error; err != nil {
+		tx.Rollback()
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to save OTP", nil)
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to save OTP", nil)
+		return
+	}
+
+	message := fmt.Sprintf("Kode OTP Meisha Alumunium Kaca: %s. Berlaku %d menit. Jangan berikan kode ini ke siapa pun.",
+		code, int(otp.CodeTTL.Minutes()))
+	if err := otp.DefaultSender().Send(input.Phone, message); err != nil {
+		log.Println("❌ Failed to send OTP:", err)
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to send OTP", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "If the number is registered, an OTP has been sent", nil)
+}
+
+// VerifyOTP godoc
+// @Summary      Verify OTP code
+// @Description  Verifikasi kode OTP. Kalau valid, akun diaktifkan (bila belum aktif) dan token login diterbitkan.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        input body object{phone=string,code=string} true "Nomor HP dan kode OTP"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      401 {object} utils.ErrorResponse
+// @Failure      429 {object} utils.ErrorResponse
+// @Router       /otp/verify [post]
+func VerifyOTP(c *gin.Context) {
+	var input struct {
+		Phone string `json:"phone" binding:"required"`
+		Code  string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+	input.Phone = utils.NormalizePhoneOrRaw(input.Phone)
+
+	switch err := consumeOTP(input.Phone, input.Code); {
+	case errors.Is(err, errOTPTooManyAttempts):
+		utils.SendErrorResponse(c, http.StatusTooManyRequests, "Too many attempts, request a new code", nil)
+		return
+	case errors.Is(err, errOTPInvalid):
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Invalid or expired code", nil)
+		return
+	case err != nil:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to verify OTP", nil)
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("phone = ?", input.Phone).First(&user).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Invalid or expired code", nil)
+		return
+	}
+
+	account, activated, err := activateByOTP(user)
+	if errors.Is(err, errAccountDeactivated) {
+		utils.SendErrorResponse(c, http.StatusForbidden, "Account deactivated", nil)
+		return
+	}
+	if err != nil {
+		log.Println("❌ Failed to activate account via OTP:", err)
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to activate account", nil)
+		return
+	}
+
+	// login lewat OTP HP tetap wajib langkah kedua kalau akun pakai 2FA
+	challenge, needed, err := secondFactorChallenge(account)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to start 2FA", nil)
+		return
+	}
+	if needed {
+		challenge["activated"] = activated
+		utils.SendSuccessResponse(c, http.StatusOK, "2FA required", challenge)
+		return
+	}
+
+	accessToken, refreshToken, err := startSession(c, account)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to generate token", nil)
+		return
+	}
+
+	setAccessTokenCookie(c, accessToken)
+	utils.SendSuccessResponse(c, http.StatusOK, "OTP verified", gin.H{
+		"token":         accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
+		"activated":     activated,
+	})
+}
+
+// throttlePhoneMessage batas kirim SMS / WA per nomor HP (dipakai bersama OTP & lupa password,
+// jadi nomor tidak bisa dibanjiri lewat endpoint mana pun). Kirim 429 kalau kena batas.
+func throttlePhoneMessage(c *gin.Context, phone string) bool {
+	switch err := utils.ThrottleOTPRequest(phone, otp.ResendInterval, otp.MaxPerHour); {
+	case errors.Is(err, utils.ErrOTPResendTooSoon):
+		utils.SendErrorResponse(c, http.StatusTooManyRequests, "Please wait before requesting a new code", nil)
+		return false
+	case errors.Is(err, utils.ErrOTPHourlyLimit):
+		utils.SendErrorResponse(c, http.StatusTooManyRequests, "Too many requests for this number, try again later", nil)
+		return false
+	}
+	return true
+}
+
+// consumeOTP cek kode OTP login terbaru untuk nomor HP lalu menandainya terpakai.
+// Percobaan dihitung lewat satu UPDATE bersyarat sebelum hash dibandingkan, jadi
+// request paralel tidak bisa melewati MaxAttempts.
+func consumeOTP(phone, code string) error {
+	var otpCode models.OTPCode
+	err := config.DB.
+		Where("phone = ? AND purpose = ? AND consumed_at IS NULL AND expires_at > ?", phone, otpPurposeLogin, time.Now()).
+		Order("created_at DESC").
+		First(&otpCode).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errOTPInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	res := config.DB.Model(&models.OTPCode{}).
+		Where("id = ? AND consumed_at IS NULL AND attempts < ?", otpCode.ID, otp.MaxAttempts).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		config.DB.Model(&models.OTPCode{}).
+			Where("id = ? AND consumed_at IS NULL", otpCode.ID).
+			Update("consumed_at", time.Now())
+		return errOTPTooManyAttempts
+	}
+
+	if !otp.CheckCode(phone, code, otpCode.CodeHash) {
+		return errOTPInvalid
+	}
+
+	// tandai terpakai (kondisi consumed_at IS NULL mencegah dipakai 2x bersamaan)
+	res = config.DB.Model(&models.OTPCode{}).
+		Where("id = ? AND consumed_at IS NULL", otpCode.ID).
+		Update("consumed_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errOTPInvalid
+	}
+	return nil
+}
+
+// activateByOTP mengaktifkan user (dan membuat account customer tanpa password kalau belum ada).
+// Nomor HP sudah terbukti milik user, jadi aman dianggap aktif.
+func activateByOTP(user models.User) (models.UserAccount, bool, error) {
+	var account models.UserAccount
+	activated := false
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if !user.IsActive {
+			if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("is_active", true).Error; err != nil {
+				return err
+			}
+			user.IsActive = true
+			activated = true
+		}
+
+		err := tx.Preload("Role").Where("user_id = ?", user.ID).First(&account).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			account, err = createCustomerAccount(tx, user)
+			return err
+		}
+		if err == nil && account.DeactivatedAt != nil {
+			return errAccountDeactivated
+		}
+		return err
+	})
+	if err != nil {
+		return account, false, err
+	}
+
+	account.User = user
+	return account, activated, nil
+}
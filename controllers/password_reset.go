@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/otp"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	passwordTokenReset      = "reset"
+	passwordTokenActivation = "activation"
+
+	resetTokenTTL      = 30 * time.Minute
+	activationTokenTTL = 72 * time.Hour
+	// batas /password/forgot per IP per jam
+	forgotPasswordPerIPHour = 20
+)
+
+// ForgotPassword godoc
+// @Summary      Request password reset / activation link
+// @Description  Kirim link reset password (akun aktif) atau link aktivasi (akun belum aktif) ke nomor HP.
+// @Description  Response selalu sama supaya nomor tidak bisa ditebak.
+// @Tags         User Accounts
+// @Accept       json
+// @Produce      json
+// @Param        input body object{phone=string} true "Nomor HP"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      429 {object} utils.ErrorResponse
+// @Router       /password/forgot [post]
+func ForgotPassword(c *gin.Context) {
+	var input struct {
+		Phone string `json:"phone" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	const genericMessage = "If the number is registered, a link has been sent"
+	input.Phone = utils.NormalizePhoneOrRaw(input.Phone)
+
+	// throttle per IP dan per nomor sebelum cek nomor terdaftar (sama dengan /otp/request)
+	if !utils.AllowRequest("password_forgot:"+c.ClientIP(), forgotPasswordPerIPHour, time.Hour) {
+		utils.SendErrorResponse(c, http.StatusTooManyRequests, "Too many requests, try again later", nil)
+		return
+	}
+	if !throttlePhoneMessage(c, input.Phone) {
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("phone = ?", input.Phone).First(&user).Error; err != nil {
+		utils.SendSuccessResponse(c, http.StatusOK, genericMessage, nil)
+		return
+	}
+
+	// user dari guest checkout belum punya account → buat account customer tanpa password
+	var account models.UserAccount
+	err := config.DB.Where("user_id = ?", user.ID).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		account, err = createCustomerAccount(config.DB, user)
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to prepare account", nil)
+		return
+	}
+
+	purpose, ttl := passwordTokenReset, resetTokenTTL
+	if !user.IsActive {
+		purpose, ttl = passwordTokenActivation, activationTokenTTL
+	}
+
+	token, err := createPasswordToken(account, purpose, ttl)
+	if err != nil {
+		log.Println("❌ Failed to create password token:", err)
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create token", nil)
+		return
+	}
+
+	if err := otp.DefaultSender().Send(user.Phone, passwordTokenMessage(purpose, token, ttl)); err != nil {
+		log.Println("❌ Failed to send password token:", err)
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to send link", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, genericMessage, nil)
+}
+
+// ResetPassword godoc
+// @Summary      Reset password with token
+// @Description  Set password baru memakai token dari /password/forgot. Akun yang belum aktif ikut diaktifkan
+// @Description  dan semua sesi login lama dicabut.
+// @Tags         User Accounts
+// @Accept       json
+// @Produce      json
+// @Param        input body object{token=string,new_password=string} true "Token dan password baru"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /password/reset [post]
+func ResetPassword(c *gin.Context) {
+	var input struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Permintaan tidak valid", nil)
+		return
+	}
+
+	var resetToken models.PasswordResetToken
+	if err := config.DB.
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", utils.HashToken(input.Token), time.Now()).
+		First(&resetToken).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Token tidak valid atau sudah kedaluwarsa", nil)
+		return
+	}
+
+	var account models.UserAccount
+	if err := config.DB.Preload("User").First(&account, "id = ?", resetToken.UserAccountID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Token tidak valid atau sudah kedaluwarsa", nil)
+		return
+	}
+
+	if err := utils.ValidatePassword(input.NewPassword, account.Phone, account.User.Name); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	newHashed, err := utils.HashPassword(input.NewPassword)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal update password", nil)
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// sekali pakai: kondisi used_at IS NULL mencegah dipakai 2x bersamaan
+		res := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", resetToken.ID).
+			Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errPasswordTokenUsed
+		}
+
+		// token lain milik account ini ikut hangus
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_account_id = ? AND used_at IS NULL", account.ID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.UserAccount{}).
+			Where("id = ?", account.ID).
+			Update("password_hash", newHashed).Error; err != nil {
+			return err
+		}
+
+		if !account.User.IsActive {
+			if err := tx.Model(&models.User{}).
+				Where("id = ?", account.UserID).
+				Update("is_active", true).Error; err != nil {
+				return err
+			}
+		}
+
+		return logPasswordChange(tx, c, account.ID, resetToken.Purpose)
+	})
+	if errors.Is(err, errPasswordTokenUsed) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Token tidak valid atau sudah kedaluwarsa", nil)
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal update password", nil)
+		return
+	}
+
+	// semua sesi lama tidak berlaku lagi
+	if err := utils.RevokeUserTokens(account.UserID.String(), ""); err != nil {
+		log.Println("⚠️ Failed to revoke sessions after password reset:", err)
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Password berhasil diperbarui", nil)
+}
+
+var errPasswordTokenUsed = errors.New("password token already used")
+
+// createPasswordToken membuat token reset / aktivasi baru dan menghanguskan token lama
+func createPasswordToken(account models.UserAccount, purpose string, ttl time.Duration) (string, error) {
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_account_id = ? AND used_at IS NULL", account.ID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.PasswordResetToken{
+			UserAccountID: account.ID,
+			TokenHash:     utils.HashToken(token),
+			Purpose:       purpose,
+			ExpiresAt:     time.Now().Add(ttl),
+		}).Error
+	})
+	return token, err
+}
+
+// passwordTokenMessage menyusun pesan berisi link (PASSWORD_RESET_URL + token) atau token saja
+func passwordTokenMessage(purpose, token string, ttl time.Duration) string {
+	link := token
+	if base := os.Getenv("PASSWORD_RESET_URL"); base != "" {
+		link = base + token
+	}
+
+	validity := fmt.Sprintf("%d menit", int(ttl.Minutes()))
+	if ttl >= time.Hour {
+		validity = fmt.Sprintf("%d jam", int(ttl.Hours()))
+	}
+
+	if purpose == passwordTokenActivation {
+		return fmt.Sprintf("Aktivasi akun Meisha Alumunium Kaca: %s (berlaku %s).", link, validity)
+	}
+	return fmt.Sprintf("Reset password Meisha Alumunium Kaca: %s (berlaku %s). Abaikan jika bukan Anda.", link, validity)
+}
+
+// logPasswordChange mencatat perubahan password ke changelog
+func logPasswordChange(tx *gorm.DB, c *gin.Context, accountID uuid.UUID, reason string) error {
+	return tx.Create(&models.PasswordChangeLog{
+		UserAccountID: accountID,
+		Reason:        reason,
+		IP:            c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+	}).Error
+}
@@ -0,0 +1,404 @@
+package controllers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Hak akses & hapus data pribadi sesuai UU PDP.
+// Export berisi semua data yang kita simpan tentang user, erasure menganonimkan
+// data pribadinya tapi row user & order tetap ada supaya statistik tidak berubah.
+
+const (
+	uploadsDir      = "uploads"
+	erasedUserName  = "Deleted user"
+	exportPhotoPath = "photo/"
+)
+
+var (
+	errAlreadyErased     = errors.New("user already erased")
+	errEraseStaffAccount = errors.New("staff account cannot be erased")
+)
+
+// PersonalDataExport isi file export data pribadi
+type PersonalDataExport struct {
+	ExportedAt    time.Time              `json:"exported_at"`
+	User          models.User            `json:"user"`
+	Accounts      []models.UserAccount   `json:"accounts"`
+	Addresses     []models.Address       `json:"addresses"`
+	Orders        []models.Order         `json:"orders"`
+	Notifications []models.Notification  `json:"notifications"`
+	Sessions      []models.Session       `json:"sessions"`
+	Notes         []models.CustomerNote  `json:"notes"` // catatan internal staff tentang user
+	Companies     []models.CompanyMember `json:"companies"`
+	Photo         string                 `json:"photo,omitempty"` // path foto di dalam ZIP
+}
+
+// ExportMyData godoc
+// @Summary      Export my personal data
+// @Description  Download semua data pribadi user yang sedang login (profil, akun, alamat, order, notifikasi, sesi, foto).
+// @Tags         Me
+// @Produce      json
+// @Produce      application/zip
+// @Param        format query string false "json (default) atau zip (termasuk file foto)"
+// @Success      200 {object} PersonalDataExport
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /me/export [get]
+func ExportMyData(c *gin.Context) {
+	sendPersonalDataExport(c, currentUserID(c))
+}
+
+// ExportUserData godoc
+// @Summary      Export personal data of a user
+// @Description  Admin: export data pribadi user untuk permintaan akses (UU PDP). Dicatat di audit log.
+// @Tags         Admin
+// @Produce      json
+// @Produce      application/zip
+// @Param        id     path  string true  "User ID"
+// @Param        format query string false "json (default) atau zip (termasuk file foto)"
+// @Success      200 {object} PersonalDataExport
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/users/{id}/export [get]
+func ExportUserData(c *gin.Context) {
+	userID := utils.ParseUUID(c.Param("id"))
+	if userID == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+	sendPersonalDataExport(c, userID)
+}
+
+func sendPersonalDataExport(c *gin.Context, userID uuid.UUID) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "format must be json or zip", nil)
+		return
+	}
+
+	export, err := collectPersonalData(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to export data", nil)
+		return
+	}
+
+	photo := localUploadPath(export.User.PhotoUrl)
+	if format == "zip" && photo != "" {
+		export.Photo = exportPhotoPath + filepath.Base(photo)
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to export data", nil)
+		return
+	}
+
+	entry := utils.AuditFromContext(c, "users.export")
+	entry.SubjectID = userID
+	entry.StatusCode = http.StatusOK
+	utils.WriteAuditLog(entry, gin.H{"format": format})
+
+	filename := "personal-data-" + userID.String()
+	if format == "json" {
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		c.Data(http.StatusOK, "application/json", data)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+	if err := writeExportZip(c.Writer, data, photo, export.Photo); err != nil {
+		// header sudah terkirim, cukup di-log
+		log.Println("❌ Failed to write export zip:", err)
+	}
+}
+
+func collectPersonalData(userID uuid.UUID) (PersonalDataExport, error) {
+	export := PersonalDataExport{ExportedAt: time.Now()}
+	if err := config.DB.Preload("Tags").First(&export.User, "id = ?", userID).Error; err != nil {
+		return export, err
+	}
+
+	queries := []struct {
+		dest  interface{}
+		query *gorm.DB
+	}{
+		{&export.Accounts, config.DB.Preload("Role").Where("user_id = ?", userID)},
+		{&export.Addresses, config.DB.Where("user_id = ?", userID)},
+		{&export.Orders, config.DB.Preload("Product").Preload("Updates").Where("user_id = ?", userID)},
+		{&export.Notifications, config.DB.Where("user_id = ?", userID)},
+		{&export.Sessions, config.DB.Where("user_id = ?", userID)},
+		{&export.Notes, config.DB.Where("user_id = ?", userID)},
+		{&export.Companies, config.DB.Preload("Company").Where("user_id = ?", userID)},
+	}
+	for _, q := range queries {
+		if err := q.query.Order("created_at ASC").Find(q.dest).Error; err != nil {
+			return export, err
+		}
+	}
+	return export, nil
+}
+
+func writeExportZip(w http.ResponseWriter, data []byte, photoPath, photoName string) error {
+	zw := zip.NewWriter(w)
+
+	f, err := zw.Create("data.json")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+
+	if photoName != "" {
+		photo, err := os.ReadFile(photoPath)
+		if err != nil {
+			log.Printf("⚠️ Export photo not readable %s: %v", photoPath, err)
+		} else {
+			f, err := zw.Create(photoName)
+			if err != nil {
+				return err
+			}
+			if _, err := f.Write(photo); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+// localUploadPath path file foto kalau memang ada di folder uploads
+func localUploadPath(photoURL string) string {
+	if photoURL == "" {
+		return ""
+	}
+	path := filepath.Clean(photoURL)
+	if !strings.HasPrefix(path, uploadsDir+string(filepath.Separator)) {
+		return ""
+	}
+	return path
+}
+
+// EraseUserData godoc
+// @Summary      Erase personal data of a user
+// @Description  Admin: anonimkan data pribadi user (profil, alamat order, akun, alamat, sesi, catatan CRM) untuk permintaan hapus data (UU PDP).
+// @Description  Row user & order tetap ada supaya statistik order tidak berubah. Tidak bisa dibatalkan.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id    path string true "User ID"
+// @Param        input body object{reason=string} true "Alasan / nomor permintaan"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      409 {object} utils.ErrorResponse
+// @Router       /admin/users/{id}/erase [post]
+func EraseUserData(c *gin.Context) {
+	var input struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "reason is required", nil)
+		return
+	}
+
+	userID := utils.ParseUUID(c.Param("id"))
+	if userID == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	// cabut semua login dulu, selagi sesi-nya masih bisa dicari
+	if err := utils.RevokeUserTokens(userID.String(), ""); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to revoke sessions", nil)
+		return
+	}
+
+	var summary gin.H
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		summary, err = eraseUser(tx, user)
+		if err != nil {
+			return err
+		}
+
+		entry := utils.AuditFromContext(c, "users.erase")
+		entry.SubjectID = userID
+		entry.StatusCode = http.StatusOK
+		summary["reason"] = input.Reason
+		return utils.CreateAuditLog(tx, entry, summary)
+	})
+	switch {
+	case errors.Is(err, errAlreadyErased):
+		utils.SendErrorResponse(c, http.StatusConflict, "User data already erased", nil)
+		return
+	case errors.Is(err, errEraseStaffAccount):
+		utils.SendErrorResponse(c, http.StatusConflict, "Staff accounts must be removed from staff before erasure", nil)
+		return
+	case err != nil:
+		log.Println("❌ Failed to erase user data:", err)
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to erase user data", nil)
+		return
+	}
+
+	// file foto dihapus setelah commit, kecuali masih dipakai user lain
+	if photo := localUploadPath(user.PhotoUrl); photo != "" {
+		var shared int64
+		config.DB.Model(&models.User{}).Where("photo_url = ?", user.PhotoUrl).Count(&shared)
+		if shared == 0 {
+			if err := utils.DeleteFile(photo); err != nil {
+				log.Printf("⚠️ Failed to delete photo %s: %v", photo, err)
+			}
+		}
+	}
+
+	log.Printf("🧹 User data erased: user=%s by=%s", userID, c.GetString("user_id"))
+	utils.SendSuccessResponse(c, http.StatusOK, "User data erased", summary)
+}
+
+// eraseUser menganonimkan data pribadi user di dalam transaksi
+func eraseUser(tx *gorm.DB, user models.User) (gin.H, error) {
+	// baca ulang dengan lock supaya 2 request erasure tidak jalan bersamaan
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", user.ID).Error; err != nil {
+		return nil, err
+	}
+	if user.ErasedAt != nil {
+		return nil, errAlreadyErased
+	}
+
+	var accounts []models.UserAccount
+	if err := tx.Preload("Role").Where("user_id = ?", user.ID).Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	phones := []string{}
+	if user.Phone != "" {
+		phones = append(phones, user.Phone)
+	}
+	accountIDs := []uuid.UUID{}
+	for _, account := range accounts {
+		if account.Role.IsStaff {
+			return nil, errEraseStaffAccount
+		}
+		accountIDs = append(accountIDs, account.ID)
+		if account.Phone != "" {
+			phones = append(phones, account.Phone)
+		}
+	}
+
+	now := time.Now()
+	summary := gin.H{"accounts": len(accountIDs)}
+
+	// akun: kredensial & nomor dihapus lalu soft delete
+	if len(accountIDs) > 0 {
+		if err := tx.Where("account_id IN ?", accountIDs).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Where("user_account_id IN ?", accountIDs).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Model(&models.PasswordChangeLog{}).Where("user_account_id IN ?", accountIDs).
+			Updates(map[string]interface{}{"ip": "", "user_agent": ""}).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Model(&models.UserAccount{}).Where("id IN ?", accountIDs).Updates(map[string]interface{}{
+			"phone":           "",
+			"password_hash":   "",
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+		}).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Where("id IN ?", accountIDs).Delete(&models.UserAccount{}).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(phones) > 0 {
+		if err := tx.Where("phone IN ?", phones).Delete(&models.OTPCode{}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// sesi tetap ada (status revoke), hanya jejak perangkatnya yang dihapus
+	if err := tx.Model(&models.Session{}).Where("user_id = ?", user.ID).
+		Updates(map[string]interface{}{"device": "", "ip": "", "user_agent": ""}).Error; err != nil {
+		return nil, err
+	}
+
+	// order: alamat & penerima dianonimkan, kabupaten/kota tetap untuk statistik
+	res := tx.Model(&models.Order{}).Where("user_id = ?", user.ID).Updates(map[string]interface{}{
+		"address":          "",
+		"address_id":       nil,
+		"address_label":    "",
+		"address_name":     "",
+		"address_phone":    "",
+		"address_district": "",
+		"address_notes":    "",
+		"address_lat":      0,
+		"address_lang":     0,
+	})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	summary["orders"] = res.RowsAffected
+
+	res = tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Address{})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	summary["addresses"] = res.RowsAffected
+
+	// data CRM ikut dihapus: catatan bisa berisi data pribadi, tag dilepas
+	res = tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.CustomerNote{})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	summary["notes"] = res.RowsAffected
+	if err := tx.Exec("DELETE FROM user_tags WHERE user_id = ?", user.ID).Error; err != nil {
+		return nil, err
+	}
+
+	// keluar dari semua company; order company tetap ada
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.CompanyMember{}).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"name":      erasedUserName,
+		"email":     "",
+		"phone":     gorm.Expr("NULL"), // kolom unique
+		"address":   "",
+		"district":  "",
+		"lat":       0,
+		"lang":      0,
+		"photo_url": "",
+		"is_active": false,
+		"erased_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
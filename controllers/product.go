@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/ary/go-api/config"
@@ -10,19 +11,26 @@ import (
 	"github.com/ary/go-api/utils"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"gorm.io/gorm"
 
 	"github.com/gin-gonic/gin"
 )
 
 // @Summary Get all products
-// @Description Retrieve a list of all products
+// @Description Retrieve a list of all products, or search them with q (full-text over name, detail and category, typo tolerant, ranked by relevance). A search without matches returns 200 with an empty list.
 // @Tags Products
 // @Produce json
+// @Param q query string false "Search text"
+// @Param limit query int false "Max results when searching (default 20, max 100)"
 // @Success 200 {array} models.Product
 // @Failure 500 {object} utils.ErrorResponse
 // @Failure 404 {object} utils.ErrorResponse
 // @Router /products [get]
 func GetProducts(c *gin.Context) {
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		searchProducts(c, q)
+		return
+	}
 	var products []models.Product
 	if err := config.DB.Preload("Category").Find(&products).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch products", nil)
@@ -36,7 +44,7 @@ func GetProducts(c *gin.Context) {
 }
 
 // @Summary Get product by ID
-// @Description Retrieve a product by its ID
+// @Description Retrieve a product by its ID, including option groups and active variants
 // @Tags Products
 // @Produce json
 // @Param id path string true "Product ID"
@@ -48,7 +56,7 @@ func GetProductByID(c *gin.Context) {
 	id := c.Param("id")
 	var product models.Product
 
-	if err := config.DB.Where("id = ?", id).First(&product).Error; err != nil {
+	if err := preloadProductOptions(config.DB).Where("id = ?", id).First(&product).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusNotFound, "product not found", nil)
 		return
 	}
@@ -84,6 +92,9 @@ func GetProductByCategoryID(c *gin.Context) {
 // @Param        name         formData string  true  "Product Name"
 // @Param        detail       formData string  true  "Product Detail (as integer)"
 // @Param        category_id  formData string  true  "Category ID (UUID)"
+// @Param        price        formData integer false "Harga dalam Rupiah per price_unit"
+// @Param        price_unit   formData string  false "Satuan harga: unit, metre, m2 (default unit)"
+// @Param        min_order_qty formData integer false "Minimum order (default 1)"
 // @Param        images       formData file    true  "Product Images (multiple upload allowed)"
 // @Success      201 {object} models.Product
 // @Failure      400 {object} utils.ErrorResponse
@@ -106,6 +117,13 @@ func CreateProduct(c *gin.Context) {
 	}
 	product.CategoryID = parsedCategoryID
 
+	product.PriceUnit = utils.PriceUnitPiece
+	product.MinOrderQty = 1
+	price, ok := priceFromForm(c, product)
+	if !ok {
+		return
+	}
+
 	// Ambil semua file dengan nama "images"
 	form, err := c.MultipartForm()
 	if err != nil {
@@ -141,10 +159,23 @@ func CreateProduct(c *gin.Context) {
 
 	product.Images = pq.StringArray(imagePaths)
 
-	if err := config.DB.Create(&product).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		if price == nil {
+			return nil
+		}
+		price.ProductID = product.ID
+		return utils.ApplyProductPrice(tx, price)
+	})
+	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create product", nil)
 		return
 	}
+	if price != nil {
+		setProductPrice(&product, price)
+	}
 
 	utils.SendSuccessResponse(c, http.StatusCreated, "Success", product)
 }
@@ -196,6 +227,9 @@ func GetBestSellingProducts(c *gin.Context) {
 // @Param name formData string false "Product Name"
 // @Param detail formData string false "Product Detail (as integer)"
 // @Param category_id formData string false "Category ID (UUID)"
+// @Param price formData integer false "Harga baru dalam Rupiah, langsung berlaku (untuk harga terjadwal pakai /products/{id}/prices)"
+// @Param price_unit formData string false "Satuan harga: unit, metre, m2"
+// @Param min_order_qty formData integer false "Minimum order"
 // @Param images formData file false "Product Images (multiple upload allowed)"
 // @Success 200 {object} models.Product
 // @Failure 400 {object} utils.ErrorResponse
@@ -206,7 +240,8 @@ func UpdateProduct(c *gin.Context) {
 	id := c.Param("id")
 	var product models.Product
 
-	// Cek apakah produk ada
+	// Cek apakah produk ada (harga terjadwal yang sudah jatuh tempo diterapkan dulu)
+	applyDuePrices(utils.ParseUUID(id))
 	if err := config.DB.First(&product, "id = ?", id).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusNotFound, "Product not found", nil)
 		return
@@ -231,6 +266,11 @@ func UpdateProduct(c *gin.Context) {
 		product.CategoryID = parsedCategoryID
 	}
 
+	price, ok := priceFromForm(c, product)
+	if !ok {
+		return
+	}
+
 	// Ganti gambar jika diberikan
 	form, err := c.MultipartForm()
 	if err == nil && form.File["images"] != nil && len(form.File["images"]) > 0 {
@@ -261,10 +301,23 @@ func UpdateProduct(c *gin.Context) {
 		product.Images = pq.StringArray(newImagePaths)
 	}
 
-	if err := config.DB.Save(&product).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// kolom harga hanya diubah lewat ProductPrice supaya riwayatnya tercatat
+		if err := tx.Omit("price", "price_unit", "min_order_qty").Save(&product).Error; err != nil {
+			return err
+		}
+		if price == nil {
+			return nil
+		}
+		return utils.ApplyProductPrice(tx, price)
+	})
+	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update product", nil)
 		return
 	}
+	if price != nil {
+		setProductPrice(&product, price)
+	}
 
 	utils.SendSuccessResponse(c, http.StatusOK, "Product updated", product)
 }
@@ -292,10 +345,28 @@ func DeleteProduct(c *gin.Context) {
 		utils.DeleteFile(img)
 	}
 
-	if err := config.DB.Delete(&product).Error; err != nil {
+	// varian (beserta gambarnya) dan rumus harga ikut dihapus
+	var variants []models.ProductVariant
+	config.DB.Where("product_id = ?", product.ID).Find(&variants)
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductVariant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.PricingFormula{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&product).Error
+	})
+	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to delete product", nil)
 		return
 	}
+	for _, variant := range variants {
+		for _, img := range variant.Images {
+			utils.DeleteFile(img)
+		}
+	}
 
 	utils.SendSuccessResponse(c, http.StatusOK, "Product deleted", nil)
 }
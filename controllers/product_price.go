@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errPriceApplied = errors.New("price already applied")
+
+type ProductPriceInput struct {
+	Amount      *int64     `json:"amount" example:"250000"`                          // Rupiah per unit
+	Unit        string     `json:"unit" example:"m2"`                                // unit, metre, m2 (default: satuan sekarang)
+	MinOrderQty *int       `json:"min_order_qty" example:"2"`                        // default: minimum sekarang
+	EffectiveAt *time.Time `json:"effective_at" example:"2025-09-01T00:00:00+07:00"` // kosong / sudah lewat = langsung berlaku
+	Note        string     `json:"note" example:"Kenaikan harga aluminium dari supplier"`
+}
+
+type ProductPricesResponse struct {
+	Product   models.Product        `json:"product"`
+	Scheduled []models.ProductPrice `json:"scheduled"` // belum berlaku, urut dari yang paling dekat
+	History   []models.ProductPrice `json:"history"`   // sudah berlaku, terbaru dulu
+}
+
+// applyDuePrices menerapkan harga terjadwal satu produk yang sudah jatuh tempo, dipakai di jalur
+// yang memakai harga (order, update & riwayat harga) supaya tidak menunggu tick scheduler.
+// Endpoint baca publik cukup mengandalkan RunPriceScheduler. Gagal hanya di-log.
+func applyDuePrices(productID uuid.UUID) {
+	if err := utils.ApplyDueProductPrices(productID); err != nil {
+		log.Println("❌ Failed to apply scheduled prices:", err)
+	}
+}
+
+// priceActor mengisi siapa yang mengubah harga (user atau API key)
+func priceActor(c *gin.Context, price *models.ProductPrice) {
+	if p := utils.CurrentPrincipal(c); p != nil {
+		price.ChangedBy = utils.ParseUUID(p.ID)
+		price.ChangedByType = p.Type
+	}
+}
+
+func setProductPrice(product *models.Product, price *models.ProductPrice) {
+	product.Price = price.Amount
+	product.PriceUnit = price.Unit
+	product.MinOrderQty = price.MinOrderQty
+}
+
+// validatePrice cek isi ProductPrice, kirim 400 kalau tidak valid
+func validatePrice(c *gin.Context, price *models.ProductPrice) bool {
+	if price.Amount < 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Price must not be negative", nil)
+		return false
+	}
+	if !utils.ValidPriceUnit(price.Unit) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid price unit (unit, metre, m2)", nil)
+		return false
+	}
+	if price.MinOrderQty < 1 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Minimum order quantity must be at least 1", nil)
+		return false
+	}
+	return true
+}
+
+// priceFromForm membaca price / price_unit / min_order_qty dari form create / update product.
+// Hasilnya nil kalau tidak ada yang diisi atau tidak ada yang berubah.
+func priceFromForm(c *gin.Context, product models.Product) (*models.ProductPrice, bool) {
+	price := &models.ProductPrice{
+		ProductID:   product.ID,
+		Amount:      product.Price,
+		Unit:        product.PriceUnit,
+		MinOrderQty: product.MinOrderQty,
+		EffectiveAt: time.Now(),
+	}
+
+	if v := strings.TrimSpace(c.PostForm("price")); v != "" {
+		amount, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid price", nil)
+			return nil, false
+		}
+		price.Amount = amount
+	}
+	if v := strings.TrimSpace(c.PostForm("price_unit")); v != "" {
+		price.Unit = strings.ToLower(v)
+	}
+	if v := strings.TrimSpace(c.PostForm("min_order_qty")); v != "" {
+		qty, err := strconv.Atoi(v)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid minimum order quantity", nil)
+			return nil, false
+		}
+		price.MinOrderQty = qty
+	}
+
+	if !validatePrice(c, price) {
+		return nil, false
+	}
+	if price.Amount == product.Price && price.Unit == product.PriceUnit && price.MinOrderQty == product.MinOrderQty {
+		return nil, true
+	}
+	priceActor(c, price)
+	return price, true
+}
+
+// GetProductPrices godoc
+// @Summary      Product price history
+// @Description  Harga sekarang, harga terjadwal yang belum berlaku, dan riwayat perubahan harga (siapa & kapan)
+// @Tags         Products
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Success      200 {object} ProductPricesResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /products/{id}/prices [get]
+func GetProductPrices(c *gin.Context) {
+	productID := utils.ParseUUID(c.Param("id"))
+	if productID == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+	applyDuePrices(productID)
+
+	var res ProductPricesResponse
+	if err := config.DB.First(&res.Product, "id = ?", productID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+	if err := config.DB.Where("product_id = ? AND applied_at IS NULL", productID).
+		Order("effective_at ASC").Find(&res.Scheduled).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch prices", nil)
+		return
+	}
+	if err := config.DB.Where("product_id = ? AND applied_at IS NOT NULL", productID).
+		Order("applied_at DESC").Find(&res.History).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch prices", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", res)
+}
+
+// SetProductPrice godoc
+// @Summary      Change or schedule a product price
+// @Description  Tanpa effective_at (atau waktunya sudah lewat) harga langsung berlaku. Dengan effective_at di masa depan harga dijadwalkan dan diterapkan otomatis saat waktunya tiba.
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Param        body body ProductPriceInput true "Harga baru"
+// @Success      201 {object} models.ProductPrice
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /products/{id}/prices [post]
+func SetProductPrice(c *gin.Context) {
+	var input ProductPriceInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Amount == nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+
+	productID := utils.ParseUUID(c.Param("id"))
+	applyDuePrices(productID)
+	var product models.Product
+	if productID == uuid.Nil || config.DB.First(&product, "id = ?", productID).Error != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+
+	price := models.ProductPrice{
+		ProductID:   product.ID,
+		Amount:      *input.Amount,
+		Unit:        product.PriceUnit,
+		MinOrderQty: product.MinOrderQty,
+		Note:        strings.TrimSpace(input.Note),
+		EffectiveAt: time.Now(),
+	}
+	if input.Unit != "" {
+		price.Unit = strings.ToLower(strings.TrimSpace(input.Unit))
+	}
+	if input.MinOrderQty != nil {
+		price.MinOrderQty = *input.MinOrderQty
+	}
+	if !validatePrice(c, &price) {
+		return
+	}
+	priceActor(c, &price)
+
+	scheduled := input.EffectiveAt != nil && input.EffectiveAt.After(price.EffectiveAt)
+	if scheduled {
+		price.EffectiveAt = *input.EffectiveAt
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if scheduled {
+			return tx.Create(&price).Error
+		}
+		return utils.ApplyProductPrice(tx, &price)
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to save price", nil)
+		return
+	}
+
+	utils.WriteAuditLog(utils.AuditFromContext(c, "products.price"), price)
+
+	message := "Price updated"
+	if scheduled {
+		message = "Price scheduled"
+	}
+	utils.SendSuccessResponse(c, http.StatusCreated, message, price)
+}
+
+// CancelProductPrice godoc
+// @Summary      Cancel a scheduled product price
+// @Description  Hanya harga terjadwal yang belum berlaku yang bisa dibatalkan
+// @Tags         Products
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Param        price_id path string true "Product price ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      409 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /products/{id}/prices/{price_id} [delete]
+func CancelProductPrice(c *gin.Context) {
+	productID := utils.ParseUUID(c.Param("id"))
+	priceID := utils.ParseUUID(c.Param("price_id"))
+	if productID == uuid.Nil || priceID == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Price not found", nil)
+		return
+	}
+
+	var price models.ProductPrice
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&price, "id = ? AND product_id = ?", priceID, productID).Error; err != nil {
+			return err
+		}
+		if price.AppliedAt != nil {
+			return errPriceApplied
+		}
+		return tx.Delete(&price).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "Price not found", nil)
+		return
+	case errors.Is(err, errPriceApplied):
+		utils.SendErrorResponse(c, http.StatusConflict, "Price is already in effect", nil)
+		return
+	case err != nil:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to cancel price", nil)
+		return
+	}
+
+	utils.WriteAuditLog(utils.AuditFromContext(c, "products.price.cancel"), price)
+	utils.SendSuccessResponse(c, http.StatusOK, "Scheduled price cancelled", nil)
+}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// skor word_similarity minimum supaya salah ketik ("alumunium") tetap ketemu
+	productSearchMinSimilarity = 0.4
+	productSearchMaxLimit      = 100
+	productSuggestMaxLimit     = 20
+)
+
+// ekspresi SQL pencarian produk (butuh config.ProductSearchReady).
+// Cabang pertama productSearchMatch sama persis dengan idx_products_search; cabang kedua
+// untuk kata kunci yang sebagian ada di nama kategori (contoh "kaca tempered").
+const (
+	productSearchMatch = `(to_tsvector('simple', f_unaccent(coalesce(products.name, '') || ' ' || coalesce(products.detail, ''))) @@ to_tsquery('simple', f_unaccent(?))
+		OR to_tsvector('simple', f_unaccent(coalesce(products.name, '') || ' ' || coalesce(products.detail, '') || ' ' || coalesce(categories.name, ''))) @@ to_tsquery('simple', f_unaccent(?))
+		OR word_similarity(f_unaccent(?), f_unaccent(lower(products.name))) >= ?
+		OR word_similarity(f_unaccent(?), f_unaccent(lower(coalesce(categories.name, '')))) >= ?)`
+	// nama produk paling berbobot, lalu kategori, lalu detail; ditambah skor fuzzy nama
+	productSearchRank = `ts_rank(
+			setweight(to_tsvector('simple', f_unaccent(coalesce(products.name, ''))), 'A') ||
+			setweight(to_tsvector('simple', f_unaccent(coalesce(categories.name, ''))), 'B') ||
+			setweight(to_tsvector('simple', f_unaccent(coalesce(products.detail, ''))), 'C'),
+			to_tsquery('simple', f_unaccent(?)))
+		+ word_similarity(f_unaccent(?), f_unaccent(lower(products.name)))`
+)
+
+type ProductSuggestion struct {
+	Type     string    `json:"type"` // product, category
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Category string    `json:"category,omitempty"`
+}
+
+// productSearchTerms kata kunci yang sudah dinormalisasi (huruf kecil, tanpa tanda baca)
+// dan bentuk tsquery prefix-nya, contoh "kaca temp" -> "kaca:* & temp:*".
+func productSearchTerms(q string) (string, string) {
+	normalized := utils.NormalizeText(q)
+	words := strings.Fields(normalized)
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return normalized, strings.Join(words, " & ")
+}
+
+func searchLimit(c *gin.Context, def, maxLimit int) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(def)))
+	if err != nil || limit < 1 {
+		return def
+	}
+	return min(limit, maxLimit)
+}
+
+// productSearchQuery produk yang cocok dengan q, urut dari yang paling relevan
+func productSearchQuery(db *gorm.DB, q string) *gorm.DB {
+	normalized, tsquery := productSearchTerms(q)
+	db = db.Model(&models.Product{}).
+		Joins("LEFT JOIN categories ON categories.id = products.category_id AND categories.deleted_at IS NULL")
+
+	if !config.ProductSearchReady {
+		// fallback tanpa extension: semua kata harus muncul di nama / detail / kategori
+		for _, word := range strings.Fields(normalized) {
+			like := "%" + utils.EscapeLike(word) + "%"
+			db = db.Where("products.name ILIKE ? OR products.detail ILIKE ? OR categories.name ILIKE ?", like, like, like)
+		}
+		return db.Order("products.name ASC")
+	}
+
+	return db.
+		Where(productSearchMatch, tsquery, tsquery,
+			normalized, productSearchMinSimilarity, normalized, productSearchMinSimilarity).
+		Order(orderByExpr(productSearchRank+" DESC, products.name ASC", tsquery, normalized))
+}
+
+// orderByExpr ORDER BY dengan parameter (Order biasa hanya menerima string tanpa argumen)
+func orderByExpr(sql string, vars ...interface{}) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: vars, WithoutParentheses: true}}
+}
+
+// searchProducts dipanggil GetProducts kalau ada ?q=
+func searchProducts(c *gin.Context, q string) {
+	if utils.NormalizeText(q) == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid search query", nil)
+		return
+	}
+
+	var products []models.Product
+	if err := productSearchQuery(config.DB, q).
+		Select("products.*").
+		Preload("Category").
+		Limit(searchLimit(c, 20, productSearchMaxLimit)).
+		Find(&products).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to search products", nil)
+		return
+	}
+	if products == nil {
+		products = []models.Product{} // tidak ada hasil tetap 200 dengan list kosong
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", products)
+}
+
+// GetProductSuggestions godoc
+// @Summary      Product search autocomplete
+// @Description  Saran nama produk & kategori saat user mengetik (prefix + toleran salah ketik)
+// @Tags         Products
+// @Produce      json
+// @Param        q     query string true  "Teks yang sedang diketik (min. 2 huruf)"
+// @Param        limit query int    false "Jumlah saran (default 8, max 20)"
+// @Success      200 {array} ProductSuggestion
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /products/suggest [get]
+func GetProductSuggestions(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	suggestions := []ProductSuggestion{}
+	if len([]rune(utils.NormalizeText(q))) < 2 {
+		utils.SendSuccessResponse(c, http.StatusOK, "Success", suggestions)
+		return
+	}
+	limit := searchLimit(c, 8, productSuggestMaxLimit)
+
+	var products []ProductSuggestion
+	if err := productSearchQuery(config.DB, q).
+		Select("'product' AS type, products.id, products.name, categories.name AS category").
+		Limit(limit).
+		Scan(&products).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch suggestions", nil)
+		return
+	}
+
+	// kategori yang cocok ditaruh di depan, maksimal 3
+	var categories []ProductSuggestion
+	normalized, tsquery := productSearchTerms(q)
+	query := config.DB.Model(&models.Category{}).Select("'category' AS type, categories.id, categories.name")
+	if config.ProductSearchReady {
+		query = query.Where(`(to_tsvector('simple', f_unaccent(categories.name)) @@ to_tsquery('simple', f_unaccent(?))
+			OR word_similarity(f_unaccent(?), f_unaccent(lower(categories.name))) >= ?)`, tsquery, normalized, productSearchMinSimilarity).
+			Order(orderByExpr("word_similarity(f_unaccent(?), f_unaccent(lower(categories.name))) DESC", normalized))
+	} else {
+		query = query.Where("categories.name ILIKE ?", "%"+utils.EscapeLike(normalized)+"%").Order("categories.name ASC")
+	}
+	if err := query.Limit(3).Scan(&categories).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch suggestions", nil)
+		return
+	}
+
+	suggestions = append(suggestions, categories...)
+	suggestions = append(suggestions, products...)
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	c.Header("Cache-Control", "public, max-age=60")
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", suggestions)
+}
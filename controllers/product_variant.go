@@ -0,0 +1,593 @@
+package controllers
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const (
+	maxOptionGroups = 5
+	maxOptionValues = 50
+)
+
+var (
+	errOptionValueInUse   = errors.New("option value is used by a variant")
+	errOptionGroupAdded   = errors.New("product already has variants")
+	errVariantSKUTaken    = errors.New("sku already used")
+	errVariantCombination = errors.New("variant combination already exists")
+)
+
+type ProductOptionGroupInput struct {
+	Name   string   `json:"name" example:"Warna"`
+	Values []string `json:"values" example:"silver,hitam,champagne,motif kayu"`
+}
+
+type ProductOptionsInput struct {
+	Groups []ProductOptionGroupInput `json:"groups"`
+}
+
+// preloadProductOptions preload group + value (urut position) dan varian aktif beserta pilihannya
+func preloadProductOptions(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("OptionGroups", func(tx *gorm.DB) *gorm.DB { return tx.Order("position ASC") }).
+		Preload("OptionGroups.Values", func(tx *gorm.DB) *gorm.DB { return tx.Order("position ASC") }).
+		Preload("Variants", "is_active = ?", true).
+		Preload("Variants.Options")
+}
+
+// saveProductImages simpan file upload ke folder uploads, kirim 500 kalau gagal
+func saveProductImages(c *gin.Context, files []*multipart.FileHeader) ([]string, bool) {
+	uploadDir := "uploads"
+	if _, err := utils.EnsureDir(uploadDir); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create upload directory", nil)
+		return nil, false
+	}
+
+	var paths []string
+	for _, file := range files {
+		path := filepath.Join(uploadDir, uuid.New().String()+filepath.Ext(file.Filename))
+		if err := c.SaveUploadedFile(file, path); err != nil {
+			for _, p := range paths {
+				utils.DeleteFile(p)
+			}
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to upload image", nil)
+			return nil, false
+		}
+		paths = append(paths, path)
+	}
+	return paths, true
+}
+
+// variantOptionsKey kunci kombinasi pilihan, tidak tergantung urutan
+func variantOptionsKey(values []models.ProductOptionValue) string {
+	ids := make([]string, len(values))
+	for i, v := range values {
+		ids[i] = v.ID.String()
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// resolveVariantOptions cek option_value_ids: harus tepat 1 value untuk setiap group produk.
+// Hasilnya urut sesuai posisi group, dipakai juga untuk nama varian.
+func resolveVariantOptions(c *gin.Context, productID uuid.UUID, rawIDs []string) ([]models.ProductOptionValue, bool) {
+	var groups []models.ProductOptionGroup
+	if err := config.DB.Preload("Values").Where("product_id = ?", productID).
+		Order("position ASC").Find(&groups).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch product options", nil)
+		return nil, false
+	}
+	if len(groups) == 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Product has no option groups", nil)
+		return nil, false
+	}
+
+	selected := map[uuid.UUID]bool{}
+	for _, raw := range rawIDs {
+		for _, part := range splitQueryList(raw) {
+			id := utils.ParseUUID(part)
+			if id == uuid.Nil {
+				utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid option value ID", nil)
+				return nil, false
+			}
+			selected[id] = true
+		}
+	}
+
+	var values []models.ProductOptionValue
+	for _, group := range groups {
+		var picked []models.ProductOptionValue
+		for _, v := range group.Values {
+			if selected[v.ID] {
+				picked = append(picked, v)
+				delete(selected, v.ID)
+			}
+		}
+		if len(picked) != 1 {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Choose exactly one value for option "+group.Name, nil)
+			return nil, false
+		}
+		values = append(values, picked[0])
+	}
+	if len(selected) > 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Option value does not belong to this product", nil)
+		return nil, false
+	}
+	return values, true
+}
+
+// resolveOrderVariant varian yang dipilih untuk order / quote: lewat variant_id atau
+// kombinasi option_value_ids. Wajib kalau produk punya varian aktif; nil kalau tidak ada.
+func resolveOrderVariant(c *gin.Context, product models.Product, variantID string, optionValueIDs []string) (*models.ProductVariant, bool) {
+	var variants []models.ProductVariant
+	if err := config.DB.Preload("Options").
+		Where("product_id = ? AND is_active = ?", product.ID, true).Find(&variants).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch product variants", nil)
+		return nil, false
+	}
+
+	switch {
+	case variantID != "":
+		id := utils.ParseUUID(variantID)
+		for i := range variants {
+			if variants[i].ID == id {
+				return &variants[i], true
+			}
+		}
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid variant ID", nil)
+		return nil, false
+	case len(optionValueIDs) > 0:
+		values, ok := resolveVariantOptions(c, product.ID, optionValueIDs)
+		if !ok {
+			return nil, false
+		}
+		key := variantOptionsKey(values)
+		for i := range variants {
+			if variantOptionsKey(variants[i].Options) == key {
+				return &variants[i], true
+			}
+		}
+		utils.SendErrorResponse(c, http.StatusBadRequest, "This option combination is not available", nil)
+		return nil, false
+	case len(variants) > 0:
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Variant is required for this product", nil)
+		return nil, false
+	}
+	return nil, true
+}
+
+// setVariantOptions tulis ulang baris join varian <-> value. Tidak lewat Association
+// karena hook BeforeCreate akan membuat ulang value dengan ID baru.
+func setVariantOptions(tx *gorm.DB, variant *models.ProductVariant) error {
+	if err := tx.Exec("DELETE FROM product_variant_options WHERE product_variant_id = ?", variant.ID).Error; err != nil {
+		return err
+	}
+	for _, v := range variant.Options {
+		if err := tx.Exec("INSERT INTO product_variant_options (product_variant_id, product_option_value_id) VALUES (?, ?)",
+			variant.ID, v.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func variantName(values []models.ProductOptionValue) string {
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = v.Value
+	}
+	return strings.Join(names, " / ")
+}
+
+// checkVariantUnique SKU dan kombinasi pilihan tidak boleh dipakai varian lain (yang belum dihapus)
+func checkVariantUnique(tx *gorm.DB, variant *models.ProductVariant) error {
+	var count int64
+	if err := tx.Model(&models.ProductVariant{}).
+		Where("sku = ? AND id <> ?", variant.SKU, variant.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errVariantSKUTaken
+	}
+
+	var others []models.ProductVariant
+	if err := tx.Preload("Options").
+		Where("product_id = ? AND id <> ?", variant.ProductID, variant.ID).Find(&others).Error; err != nil {
+		return err
+	}
+	key := variantOptionsKey(variant.Options)
+	for _, other := range others {
+		if variantOptionsKey(other.Options) == key {
+			return errVariantCombination
+		}
+	}
+	return nil
+}
+
+// variantFromForm baca sku / price_modifier / is_active / option_value_ids dari multipart form.
+// Field yang tidak dikirim dibiarkan (untuk PATCH).
+func variantFromForm(c *gin.Context, variant *models.ProductVariant) bool {
+	if _, ok := c.GetPostForm("sku"); ok {
+		variant.SKU = strings.ToUpper(strings.TrimSpace(c.PostForm("sku")))
+	}
+	if v := strings.TrimSpace(c.PostForm("price_modifier")); v != "" {
+		modifier, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid price modifier", nil)
+			return false
+		}
+		variant.PriceModifier = modifier
+	}
+	if v := strings.TrimSpace(c.PostForm("is_active")); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid is_active", nil)
+			return false
+		}
+		variant.IsActive = active
+	}
+	if ids := c.PostFormArray("option_value_ids"); len(ids) > 0 {
+		values, ok := resolveVariantOptions(c, variant.ProductID, ids)
+		if !ok {
+			return false
+		}
+		variant.Options = values
+		variant.Name = variantName(values)
+	}
+
+	if variant.SKU == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "SKU is required", nil)
+		return false
+	}
+	if len(variant.Options) == 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "option_value_ids is required", nil)
+		return false
+	}
+	return true
+}
+
+func variantSaveResult(c *gin.Context, err error, failMessage string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errVariantSKUTaken):
+		utils.SendErrorResponse(c, http.StatusConflict, "SKU is already used", nil)
+	case errors.Is(err, errVariantCombination):
+		utils.SendErrorResponse(c, http.StatusConflict, "A variant with the same options already exists", nil)
+	default:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, failMessage, nil)
+	}
+	return false
+}
+
+// SetProductOptions godoc
+// @Summary      Set product option groups
+// @Description  Ganti daftar pilihan produk (contoh: Warna, Ketebalan). Group / value dicocokkan berdasarkan nama sehingga ID lama tetap. Value yang masih dipakai varian tidak bisa dihapus, dan group baru tidak bisa ditambah selama produk masih punya varian.
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Param        body body ProductOptionsInput true "Option groups"
+// @Success      200 {array} models.ProductOptionGroup
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      409 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /products/{id}/options [put]
+func SetProductOptions(c *gin.Context) {
+	var input ProductOptionsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+	if len(input.Groups) > maxOptionGroups {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Too many option groups (max "+strconv.Itoa(maxOptionGroups)+")", nil)
+		return
+	}
+
+	seenGroups := map[string]bool{}
+	for i := range input.Groups {
+		group := &input.Groups[i]
+		group.Name = strings.TrimSpace(group.Name)
+		key := strings.ToLower(group.Name)
+		if group.Name == "" || seenGroups[key] {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Option group names must be unique and not empty", nil)
+			return
+		}
+		seenGroups[key] = true
+
+		if len(group.Values) == 0 || len(group.Values) > maxOptionValues {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "Option group "+group.Name+" must have 1-"+strconv.Itoa(maxOptionValues)+" values", nil)
+			return
+		}
+		seenValues := map[string]bool{}
+		for j := range group.Values {
+			group.Values[j] = strings.TrimSpace(group.Values[j])
+			vkey := strings.ToLower(group.Values[j])
+			if group.Values[j] == "" || seenValues[vkey] {
+				utils.SendErrorResponse(c, http.StatusBadRequest, "Values of option group "+group.Name+" must be unique and not empty", nil)
+				return
+			}
+			seenValues[vkey] = true
+		}
+	}
+
+	var product models.Product
+	if config.DB.First(&product, "id = ?", utils.ParseUUID(c.Param("id"))).Error != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []models.ProductOptionGroup
+		if err := tx.Preload("Values").Where("product_id = ?", product.ID).Find(&existing).Error; err != nil {
+			return err
+		}
+		byName := map[string]*models.ProductOptionGroup{}
+		for i := range existing {
+			byName[strings.ToLower(existing[i].Name)] = &existing[i]
+		}
+
+		var variantCount int64
+		if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&variantCount).Error; err != nil {
+			return err
+		}
+
+		var removed []uuid.UUID // value yang tidak ada lagi di input
+		for pos, in := range input.Groups {
+			group, ok := byName[strings.ToLower(in.Name)]
+			if !ok {
+				if variantCount > 0 {
+					return errOptionGroupAdded
+				}
+				group = &models.ProductOptionGroup{ProductID: product.ID, Name: in.Name, Position: pos}
+				if err := tx.Create(group).Error; err != nil {
+					return err
+				}
+			} else {
+				delete(byName, strings.ToLower(in.Name))
+				if err := tx.Model(group).Updates(map[string]interface{}{"name": in.Name, "position": pos}).Error; err != nil {
+					return err
+				}
+			}
+
+			oldValues := map[string]models.ProductOptionValue{}
+			for _, v := range group.Values {
+				oldValues[strings.ToLower(v.Value)] = v
+			}
+			for vpos, name := range in.Values {
+				if old, ok := oldValues[strings.ToLower(name)]; ok {
+					delete(oldValues, strings.ToLower(name))
+					if err := tx.Model(&old).Updates(map[string]interface{}{"value": name, "position": vpos}).Error; err != nil {
+						return err
+					}
+					continue
+				}
+				value := models.ProductOptionValue{GroupID: group.ID, Value: name, Position: vpos}
+				if err := tx.Create(&value).Error; err != nil {
+					return err
+				}
+			}
+			for _, v := range oldValues {
+				removed = append(removed, v.ID)
+			}
+		}
+
+		var removedGroups []uuid.UUID
+		for _, group := range byName {
+			removedGroups = append(removedGroups, group.ID)
+			for _, v := range group.Values {
+				removed = append(removed, v.ID)
+			}
+		}
+		if len(removed) == 0 {
+			return nil
+		}
+
+		// value yang masih dipakai varian aktif (belum dihapus) tidak boleh hilang
+		var inUse int64
+		if err := tx.Table("product_variant_options").
+			Joins("JOIN product_variants ON product_variants.id = product_variant_options.product_variant_id").
+			Where("product_variant_options.product_option_value_id IN ? AND product_variants.deleted_at IS NULL", removed).
+			Count(&inUse).Error; err != nil {
+			return err
+		}
+		if inUse > 0 {
+			return errOptionValueInUse
+		}
+
+		if err := tx.Exec("DELETE FROM product_variant_options WHERE product_option_value_id IN ?", removed).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", removed).Delete(&models.ProductOptionValue{}).Error; err != nil {
+			return err
+		}
+		if len(removedGroups) > 0 {
+			return tx.Where("id IN ?", removedGroups).Delete(&models.ProductOptionGroup{}).Error
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errOptionGroupAdded):
+		utils.SendErrorResponse(c, http.StatusConflict, "Cannot add an option group while the product has variants", nil)
+		return
+	case errors.Is(err, errOptionValueInUse):
+		utils.SendErrorResponse(c, http.StatusConflict, "Option value is still used by a variant", nil)
+		return
+	case err != nil:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to save product options", nil)
+		return
+	}
+
+	if err := preloadProductOptions(config.DB).First(&product, "id = ?", product.ID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch product options", nil)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Product options saved", product.OptionGroups)
+}
+
+// CreateProductVariant godoc
+// @Summary      Create a product variant
+// @Description  Varian = 1 value dari setiap option group produk, dengan SKU, selisih harga dan gambar sendiri
+// @Tags         Products
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id               path     string  true  "Product ID"
+// @Param        sku              formData string  true  "SKU (unik)"
+// @Param        price_modifier   formData integer false "Selisih harga dari harga produk (Rupiah, boleh negatif)"
+// @Param        is_active        formData boolean false "Default true"
+// @Param        option_value_ids formData []string true "ID value, 1 untuk setiap option group" collectionFormat(multi)
+// @Param        images           formData file    false "Variant Images (multiple upload allowed)"
+// @Success      201 {object} models.ProductVariant
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      409 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /products/{id}/variants [post]
+func CreateProductVariant(c *gin.Context) {
+	var product models.Product
+	if config.DB.First(&product, "id = ?", utils.ParseUUID(c.Param("id"))).Error != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+
+	variant := models.ProductVariant{ProductID: product.ID, IsActive: true}
+	if !variantFromForm(c, &variant) {
+		return
+	}
+
+	if form, err := c.MultipartForm(); err == nil && len(form.File["images"]) > 0 {
+		paths, ok := saveProductImages(c, form.File["images"])
+		if !ok {
+			return
+		}
+		variant.Images = pq.StringArray(paths)
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkVariantUnique(tx, &variant); err != nil {
+			return err
+		}
+		if err := tx.Omit("Options").Create(&variant).Error; err != nil {
+			return err
+		}
+		return setVariantOptions(tx, &variant)
+	})
+	if !variantSaveResult(c, err, "Failed to create variant") {
+		for _, img := range variant.Images {
+			utils.DeleteFile(img)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, "Success", variant)
+}
+
+// UpdateProductVariant godoc
+// @Summary      Update a product variant
+// @Description  Field yang tidak dikirim tidak berubah. Gambar baru menggantikan semua gambar lama.
+// @Tags         Products
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id               path     string  true  "Product ID"
+// @Param        variant_id       path     string  true  "Variant ID"
+// @Param        sku              formData string  false "SKU (unik)"
+// @Param        price_modifier   formData integer false "Selisih harga dari harga produk (Rupiah, boleh negatif)"
+// @Param        is_active        formData boolean false "Aktif / nonaktif"
+// @Param        option_value_ids formData []string false "ID value, 1 untuk setiap option group" collectionFormat(multi)
+// @Param        images           formData file    false "Variant Images (multiple upload allowed)"
+// @Success      200 {object} models.ProductVariant
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      409 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /products/{id}/variants/{variant_id} [patch]
+func UpdateProductVariant(c *gin.Context) {
+	var variant models.ProductVariant
+	if err := config.DB.Preload("Options").First(&variant, "id = ? AND product_id = ?",
+		utils.ParseUUID(c.Param("variant_id")), utils.ParseUUID(c.Param("id"))).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Variant not found", nil)
+		return
+	}
+
+	if !variantFromForm(c, &variant) {
+		return
+	}
+
+	oldImages := variant.Images
+	var newImages []string
+	if form, err := c.MultipartForm(); err == nil && len(form.File["images"]) > 0 {
+		paths, ok := saveProductImages(c, form.File["images"])
+		if !ok {
+			return
+		}
+		newImages = paths
+		variant.Images = pq.StringArray(paths)
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkVariantUnique(tx, &variant); err != nil {
+			return err
+		}
+		if err := tx.Omit("Options").Save(&variant).Error; err != nil {
+			return err
+		}
+		return setVariantOptions(tx, &variant)
+	})
+	if !variantSaveResult(c, err, "Failed to update variant") {
+		for _, img := range newImages {
+			utils.DeleteFile(img)
+		}
+		return
+	}
+
+	if newImages != nil {
+		for _, img := range oldImages {
+			utils.DeleteFile(img)
+		}
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Variant updated", variant)
+}
+
+// DeleteProductVariant godoc
+// @Summary      Delete a product variant
+// @Description  Order lama tetap menyimpan SKU & nama varian
+// @Tags         Products
+// @Produce      json
+// @Param        id         path string true "Product ID"
+// @Param        variant_id path string true "Variant ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /products/{id}/variants/{variant_id} [delete]
+func DeleteProductVariant(c *gin.Context) {
+	var variant models.ProductVariant
+	if err := config.DB.First(&variant, "id = ? AND product_id = ?",
+		utils.ParseUUID(c.Param("variant_id")), utils.ParseUUID(c.Param("id"))).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Variant not found", nil)
+		return
+	}
+
+	if err := config.DB.Delete(&variant).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to delete variant", nil)
+		return
+	}
+	for _, img := range variant.Images {
+		utils.DeleteFile(img)
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Variant deleted", nil)
+}
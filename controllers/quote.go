@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/pricing"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// quote publik disimpan ke DB, jadi dibatasi per IP (quote expired dibersihkan utils.RunQuoteCleanup)
+const (
+	quoteRateLimit  = 30
+	quoteRateWindow = time.Minute
+)
+
+var errQuoteUsed = errors.New("quote already used")
+
+type PricingFormulaInput struct {
+	AreaRate        int64   `json:"area_rate" example:"450000"`     // Rupiah per m²
+	PerimeterRate   int64   `json:"perimeter_rate" example:"85000"` // Rupiah per meter keliling
+	PieceFee        int64   `json:"piece_fee" example:"150000"`     // Rupiah per pcs
+	MinAreaM2       float64 `json:"min_area_m2" example:"0.5"`
+	MinCharge       int64   `json:"min_charge" example:"500000"`
+	MinWidthMM      int     `json:"min_width_mm" example:"300"`
+	MaxWidthMM      int     `json:"max_width_mm" example:"3000"`
+	MinHeightMM     int     `json:"min_height_mm" example:"300"`
+	MaxHeightMM     int     `json:"max_height_mm" example:"2400"`
+	DimensionStepMM int     `json:"dimension_step_mm" example:"10"`
+	RoundTo         int64   `json:"round_to" example:"1000"`
+	QuoteValidDays  int     `json:"quote_valid_days" example:"7"`
+}
+
+type QuoteInput struct {
+	WidthMM        int      `json:"width_mm" example:"1200"`
+	HeightMM       int      `json:"height_mm" example:"1500"`
+	Quantity       int      `json:"quantity" example:"2"`
+	VariantID      string   `json:"variant_id" example:"4cd64505-f56f-421b-9243-e6bc9cbbaa7b"` // atau option_value_ids
+	OptionValueIDs []string `json:"option_value_ids"`                                          // 1 value per option group
+}
+
+func validateFormula(in PricingFormulaInput) string {
+	switch {
+	case in.AreaRate < 0 || in.PerimeterRate < 0 || in.PieceFee < 0 || in.MinCharge < 0 || in.RoundTo < 0:
+		return "Rates and charges must not be negative"
+	case in.AreaRate == 0 && in.PerimeterRate == 0 && in.PieceFee == 0:
+		return "At least one of area_rate, perimeter_rate or piece_fee is required"
+	case in.MinAreaM2 < 0 || in.DimensionStepMM < 0 || in.QuoteValidDays < 0:
+		return "Minimum area, dimension step and quote validity must not be negative"
+	case in.MinWidthMM < 0 || in.MaxWidthMM < 0 || in.MinHeightMM < 0 || in.MaxHeightMM < 0:
+		return "Dimension limits must not be negative"
+	case in.MaxWidthMM > 0 && in.MinWidthMM > in.MaxWidthMM, in.MaxHeightMM > 0 && in.MinHeightMM > in.MaxHeightMM:
+		return "Minimum dimension must not exceed maximum dimension"
+	}
+	return ""
+}
+
+// GetPricingFormula godoc
+// @Summary      Get product pricing formula
+// @Description  Rumus harga ukuran custom (lebar x tinggi) untuk produk. Butuh permission catalog:write.
+// @Tags         Products
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Success      200 {object} models.PricingFormula
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      401 {object} utils.ErrorResponse
+// @Failure      403 {object} utils.ErrorResponse
+// @Router       /products/{id}/formula [get]
+func GetPricingFormula(c *gin.Context) {
+	var formula models.PricingFormula
+	if err := config.DB.First(&formula, "product_id = ?", utils.ParseUUID(c.Param("id"))).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Pricing formula not found", nil)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", formula)
+}
+
+// SetPricingFormula godoc
+// @Summary      Create or replace product pricing formula
+// @Description  Total = max(luas, min_area_m2) x (area_rate + price_modifier varian) + keliling x perimeter_rate + piece_fee, per pcs x quantity; minimal min_charge lalu dibulatkan ke atas ke round_to. Ukuran dibulatkan ke atas ke dimension_step_mm. Butuh permission catalog:write.
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Param        body body PricingFormulaInput true "Formula"
+// @Success      200 {object} models.PricingFormula
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Failure      401 {object} utils.ErrorResponse
+// @Failure      403 {object} utils.ErrorResponse
+// @Router       /products/{id}/formula [put]
+func SetPricingFormula(c *gin.Context) {
+	var input PricingFormulaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+	if msg := validateFormula(input); msg != "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, msg, nil)
+		return
+	}
+
+	var product models.Product
+	if config.DB.First(&product, "id = ?", utils.ParseUUID(c.Param("id"))).Error != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+
+	var formula models.PricingFormula
+	err := config.DB.Where("product_id = ?", product.ID).First(&formula).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch pricing formula", nil)
+		return
+	}
+
+	formula.ProductID = product.ID
+	formula.AreaRate = input.AreaRate
+	formula.PerimeterRate = input.PerimeterRate
+	formula.PieceFee = input.PieceFee
+	formula.MinAreaM2 = input.MinAreaM2
+	formula.MinCharge = input.MinCharge
+	formula.MinWidthMM = input.MinWidthMM
+	formula.MaxWidthMM = input.MaxWidthMM
+	formula.MinHeightMM = input.MinHeightMM
+	formula.MaxHeightMM = input.MaxHeightMM
+	formula.DimensionStepMM = input.DimensionStepMM
+	formula.RoundTo = input.RoundTo
+	formula.QuoteValidDays = input.QuoteValidDays
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = config.DB.Create(&formula).Error
+	} else {
+		err = config.DB.Save(&formula).Error
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to save pricing formula", nil)
+		return
+	}
+
+	utils.WriteAuditLog(utils.AuditFromContext(c, "products.formula"), formula)
+	utils.SendSuccessResponse(c, http.StatusOK, "Pricing formula saved", formula)
+}
+
+// DeletePricingFormula godoc
+// @Summary      Delete product pricing formula
+// @Description  Produk tidak bisa di-quote lagi; quote & order lama tidak berubah. Butuh permission catalog:write.
+// @Tags         Products
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Failure      401 {object} utils.ErrorResponse
+// @Failure      403 {object} utils.ErrorResponse
+// @Router       /products/{id}/formula [delete]
+func DeletePricingFormula(c *gin.Context) {
+	res := config.DB.Where("product_id = ?", utils.ParseUUID(c.Param("id"))).Delete(&models.PricingFormula{})
+	if res.Error != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to delete pricing formula", nil)
+		return
+	}
+	if res.RowsAffected == 0 {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Pricing formula not found", nil)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Pricing formula deleted", nil)
+}
+
+// QuoteProduct godoc
+// @Summary      Calculate a price quote for a made-to-measure product
+// @Description  Hitung harga dari lebar x tinggi (mm), jumlah dan varian memakai rumus produk. Hasilnya disimpan sampai expires_at; kirim quote_id saat membuat order supaya order memakai harga ini. Quote expired yang tidak jadi order dihapus otomatis. Dibatasi 30 request per menit per IP.
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Param        body body QuoteInput true "Ukuran, jumlah & varian"
+// @Success      201 {object} models.OrderQuote
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      429 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /products/{id}/quote [post]
+func QuoteProduct(c *gin.Context) {
+	if !utils.AllowRequest("quote:"+c.ClientIP(), quoteRateLimit, quoteRateWindow) {
+		utils.SendErrorResponse(c, http.StatusTooManyRequests, "Too many quote requests, try again later", nil)
+		return
+	}
+
+	var input QuoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+
+	var product models.Product
+	if config.DB.First(&product, "id = ?", utils.ParseUUID(c.Param("id"))).Error != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+	var formula models.PricingFormula
+	if config.DB.First(&formula, "product_id = ?", product.ID).Error != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "This product has no pricing formula", nil)
+		return
+	}
+
+	variant, ok := resolveOrderVariant(c, product, input.VariantID, input.OptionValueIDs)
+	if !ok {
+		return
+	}
+
+	calc := pricing.Input{WidthMM: input.WidthMM, HeightMM: input.HeightMM, Quantity: input.Quantity}
+	if variant != nil {
+		calc.RateModifier = variant.PriceModifier
+	}
+	quote, err := pricing.Calculate(formula, calc)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	quote.ProductID = product.ID
+	if variant != nil {
+		quote.VariantID = &variant.ID
+	}
+	if err := config.DB.Create(quote).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to save quote", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, "Success", quote)
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SessionResponse struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+// GetMySessions godoc
+// @Summary      List my sessions
+// @Description  Semua perangkat yang sedang login dengan akun ini
+// @Tags         Me
+// @Produce      json
+// @Success      200 {array} controllers.SessionResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /me/sessions [get]
+func GetMySessions(c *gin.Context) {
+	var sessions []models.Session
+	if err := config.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", currentUserID(c), time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch sessions", nil)
+		return
+	}
+
+	currentSessionID := ""
+	if claims := currentClaims(c); claims != nil {
+		currentSessionID = claims.SessionID
+	}
+
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		response = append(response, SessionResponse{Session: s, Current: s.ID.String() == currentSessionID})
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", response)
+}
+
+// RevokeMySession godoc
+// @Summary      Sign out a session
+// @Description  Logout dari perangkat lain (atau perangkat ini)
+// @Tags         Me
+// @Produce      json
+// @Param        id path string true "Session ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /me/sessions/{id} [delete]
+func RevokeMySession(c *gin.Context) {
+	id := utils.ParseUUID(c.Param("id"))
+	if id == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid session ID", nil)
+		return
+	}
+
+	var session models.Session
+	if err := config.DB.First(&session, "id = ? AND user_id = ?", id, currentUserID(c)).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Session not found", nil)
+		return
+	}
+
+	if err := utils.RevokeRefreshFamily(session.ID.String()); err != nil {
+		log.Println("❌ Failed to revoke session:", err)
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to revoke session", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Session revoked", gin.H{"id": session.ID})
+}
+
+// RevokeUserSessions godoc
+// @Summary      Sign out all sessions of a user
+// @Description  Admin: cabut semua sesi login milik user
+// @Tags         Admin
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /admin/users/{id}/sessions [delete]
+func RevokeUserSessions(c *gin.Context) {
+	id := utils.ParseUUID(c.Param("id"))
+	if id == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	if err := utils.RevokeUserTokens(id.String(), ""); err != nil {
+		log.Println("❌ Failed to revoke user sessions:", err)
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to revoke sessions", nil)
+		return
+	}
+
+	log.Printf("🔒 All sessions revoked: user=%s by=%s", id, c.GetString("user_id"))
+	utils.SendSuccessResponse(c, http.StatusOK, "All sessions revoked", gin.H{"user_id": id})
+}
@@ -0,0 +1,377 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/otp"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// findRole mencari role berdasarkan nama
+func findRole(db *gorm.DB, name string) (models.Role, error) {
+	var role models.Role
+	err := db.Where("name = ?", utils.NormalizeRole(name)).First(&role).Error
+	return role, err
+}
+
+// createCustomerAccount membuat account customer tanpa password untuk user yang sudah ada
+func createCustomerAccount(db *gorm.DB, user models.User) (models.UserAccount, error) {
+	role, err := findRole(db, utils.RoleCustomer)
+	if err != nil {
+		return models.UserAccount{}, err
+	}
+
+	account := models.UserAccount{
+		Phone:  user.Phone,
+		RoleID: role.ID,
+		UserID: user.ID,
+	}
+	if err := db.Create(&account).Error; err != nil {
+		return account, err
+	}
+
+	account.Role = role
+	account.User = user
+	return account, nil
+}
+
+// GetStaff godoc
+// @Summary      List staff
+// @Description  Semua akun dengan role staff (admin, sales, installer, workshop, dst)
+// @Tags         Staff
+// @Produce      json
+// @Param        include_inactive query bool false "Ikut tampilkan akun yang dinonaktifkan"
+// @Success      200 {array} models.UserAccount
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /admin/staff [get]
+func GetStaff(c *gin.Context) {
+	query := config.DB.Preload("User").Preload("Role").
+		Joins("JOIN roles ON roles.id = user_accounts.role_id").
+		Where("roles.is_staff = ?", true)
+
+	if c.Query("include_inactive") != "true" {
+		query = query.Where("user_accounts.deactivated_at IS NULL")
+	}
+
+	var accounts []models.UserAccount
+	if err := query.Order("user_accounts.created_at DESC").Find(&accounts).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch staff", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", accounts)
+}
+
+// InviteStaff godoc
+// @Summary      Invite staff
+// @Description  Undang staff lewat nomor HP. User & akun dibuat kalau belum ada, lalu link aktivasi dikirim.
+// @Tags         Staff
+// @Accept       json
+// @Produce      json
+// @Param        input body object{phone=string,name=string,role=string} true "Data staff"
+// @Success      201 {object} models.UserAccount
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /admin/staff/invite [post]
+func InviteStaff(c *gin.Context) {
+	var input struct {
+		Phone string `json:"phone" binding:"required"`
+		Name  string `json:"name"`
+		Role  string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	if !normalizeInputPhone(c, &input.Phone) {
+		return
+	}
+
+	role, err := findRole(config.DB, input.Role)
+	if err != nil || !role.IsStaff {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid staff role", nil)
+		return
+	}
+
+	var account models.UserAccount
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		err := tx.Where("phone = ?", input.Phone).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			user = models.User{Name: input.Name, Phone: input.Phone, IsActive: false}
+			err = tx.Create(&user).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_id = ?", user.ID).First(&account).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			account = models.UserAccount{Phone: user.Phone, RoleID: role.ID, UserID: user.ID}
+			err = tx.Create(&account).Error
+		} else if err == nil {
+			err = tx.Model(&account).Updates(map[string]interface{}{"role_id": role.ID, "deactivated_at": nil}).Error
+		}
+		account.User = user
+		account.Role = role
+		return err
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to invite staff", nil)
+		return
+	}
+
+	// token lama dengan role sebelumnya tidak berlaku lagi
+	if err := utils.RevokeUserTokens(account.UserID.String(), ""); err != nil {
+		log.Println("⚠️ Failed to revoke sessions after role change:", err)
+	}
+
+	// kirim link aktivasi (atau reset password kalau user sudah aktif)
+	purpose, ttl := passwordTokenActivation, activationTokenTTL
+	if account.User.IsActive {
+		purpose, ttl = passwordTokenReset, resetTokenTTL
+	}
+	token, err := createPasswordToken(account, purpose, ttl)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create activation link", nil)
+		return
+	}
+	message := fmt.Sprintf("Anda diundang sebagai %s. %s", role.Name, passwordTokenMessage(purpose, token, ttl))
+	if err := otp.DefaultSender().Send(account.Phone, message); err != nil {
+		log.Println("❌ Failed to send staff invitation:", err)
+	}
+
+	log.Printf("👤 Staff invited: phone=%s role=%s by=%s", account.Phone, role.Name, c.GetString("user_id"))
+	utils.SendSuccessResponse(c, http.StatusCreated, "Staff invited", account)
+}
+
+// UpdateStaffRole godoc
+// @Summary      Assign staff role
+// @Tags         Staff
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "User Account ID"
+// @Param        input body object{role=string} true "Role baru"
+// @Success      200 {object} models.UserAccount
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/staff/{id}/role [patch]
+func UpdateStaffRole(c *gin.Context) {
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	account, ok := findStaffAccount(c)
+	if !ok {
+		return
+	}
+
+	role, err := findRole(config.DB, input.Role)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid role", nil)
+		return
+	}
+
+	if account.UserID.String() == c.GetString("user_id") && role.Name != utils.RoleAdmin {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "You cannot remove your own admin role", nil)
+		return
+	}
+
+	if err := config.DB.Model(&account).Update("role_id", role.ID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update role", nil)
+		return
+	}
+	account.Role = role
+
+	if err := utils.RevokeUserTokens(account.UserID.String(), ""); err != nil {
+		log.Println("⚠️ Failed to revoke sessions after role change:", err)
+	}
+
+	log.Printf("👤 Role changed: account=%s role=%s by=%s", account.ID, role.Name, c.GetString("user_id"))
+	utils.SendSuccessResponse(c, http.StatusOK, "Role updated", account)
+}
+
+// DeactivateStaff godoc
+// @Summary      Deactivate staff account
+// @Description  Nonaktifkan akun dan cabut semua sesi login-nya
+// @Tags         Staff
+// @Produce      json
+// @Param        id path string true "User Account ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/staff/{id}/deactivate [post]
+func DeactivateStaff(c *gin.Context) {
+	account, ok := findStaffAccount(c)
+	if !ok {
+		return
+	}
+
+	if account.UserID.String() == c.GetString("user_id") {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "You cannot deactivate your own account", nil)
+		return
+	}
+
+	now := time.Now()
+	if err := config.DB.Model(&account).Update("deactivated_at", now).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to deactivate account", nil)
+		return
+	}
+
+	if err := utils.RevokeUserTokens(account.UserID.String(), ""); err != nil {
+		log.Println("⚠️ Failed to revoke sessions after deactivation:", err)
+	}
+
+	log.Printf("👤 Account deactivated: account=%s by=%s", account.ID, c.GetString("user_id"))
+	utils.SendSuccessResponse(c, http.StatusOK, "Account deactivated", gin.H{"id": account.ID, "deactivated_at": now})
+}
+
+// ReactivateStaff godoc
+// @Summary      Reactivate staff account
+// @Tags         Staff
+// @Produce      json
+// @Param        id path string true "User Account ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/staff/{id}/reactivate [post]
+func ReactivateStaff(c *gin.Context) {
+	account, ok := findStaffAccount(c)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Model(&account).Update("deactivated_at", nil).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to reactivate account", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Account reactivated", gin.H{"id": account.ID})
+}
+
+// findStaffAccount hanya account dengan role staff; account customer dianggap tidak ada
+func findStaffAccount(c *gin.Context) (models.UserAccount, bool) {
+	var account models.UserAccount
+	id := utils.ParseUUID(c.Param("id"))
+	if id == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid account ID", nil)
+		return account, false
+	}
+	if err := config.DB.Preload("User").Preload("Role").First(&account, "id = ?", id).Error; err != nil || !account.Role.IsStaff {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Account not found", nil)
+		return account, false
+	}
+	return account, true
+}
+
+// GetRoles godoc
+// @Summary      List roles
+// @Description  Semua role beserta permission-nya
+// @Tags         Staff
+// @Produce      json
+// @Success      200 {array} models.Role
+// @Router       /admin/roles [get]
+func GetRoles(c *gin.Context) {
+	var roles []models.Role
+	if err := config.DB.Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch roles", nil)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", roles)
+}
+
+// GetPermissions godoc
+// @Summary      List permissions
+// @Tags         Staff
+// @Produce      json
+// @Success      200 {array} models.Permission
+// @Router       /admin/permissions [get]
+func GetPermissions(c *gin.Context) {
+	var permissions []models.Permission
+	if err := config.DB.Order("name").Find(&permissions).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch permissions", nil)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", permissions)
+}
+
+// UpdateRolePermissions godoc
+// @Summary      Set role permissions
+// @Description  Ganti seluruh permission milik role
+// @Tags         Staff
+// @Accept       json
+// @Produce      json
+// @Param        name path string true "Nama role"
+// @Param        input body object{permissions=[]string} true "Daftar permission"
+// @Success      200 {object} models.Role
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/roles/{name}/permissions [put]
+func UpdateRolePermissions(c *gin.Context) {
+	var input struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	role, err := findRole(config.DB, c.Param("name"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Role not found", nil)
+		return
+	}
+
+	// admin harus tetap bisa kelola staff, supaya tidak terkunci
+	if role.Name == utils.RoleAdmin && !containsString(input.Permissions, utils.PermStaffManage) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Admin role must keep "+utils.PermStaffManage, nil)
+		return
+	}
+
+	var permissions []models.Permission
+	if len(input.Permissions) > 0 {
+		if err := config.DB.Where("name IN ?", input.Permissions).Find(&permissions).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch permissions", nil)
+			return
+		}
+	}
+	if len(permissions) != len(input.Permissions) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Unknown permission in list", nil)
+		return
+	}
+
+	if err := config.DB.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update permissions", nil)
+		return
+	}
+	role.Permissions = permissions
+
+	if err := utils.LoadRolePermissions(); err != nil {
+		log.Println("⚠️ Failed to reload role permissions:", err)
+	}
+
+	log.Printf("👤 Role permissions updated: role=%s permissions=%v by=%s", role.Name, input.Permissions, c.GetString("user_id"))
+	utils.SendSuccessResponse(c, http.StatusOK, "Permissions updated", role)
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
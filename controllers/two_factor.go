@@ -0,0 +1,593 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Login 2 langkah: setelah password / OTP HP benar, akun dengan 2FA aktif (atau role
+// yang wajib 2FA) menerima mfa_token, lalu menukarnya di /login/2fa dengan kode authenticator
+// atau recovery code.
+
+const recoveryCodeCount = 10
+
+var (
+	errTOTPAlreadyEnabled = errors.New("2FA already enabled")
+	errTOTPNotEnabled     = errors.New("2FA not enabled")
+	errInvalidTOTPCode    = errors.New("invalid 2FA code")
+)
+
+// secondFactorChallenge mengembalikan body respons langkah kedua kalau akun butuh 2FA
+func secondFactorChallenge(account models.UserAccount) (gin.H, bool, error) {
+	enabled := account.TOTPEnabledAt != nil
+	if !enabled && !utils.TOTPRequiredForRole(account.Role.Name) {
+		return nil, false, nil
+	}
+
+	token, err := utils.IssueMFAToken(utils.MFAChallenge{AccountID: account.ID.String(), Phone: account.Phone})
+	if err != nil {
+		return nil, false, err
+	}
+
+	body := gin.H{
+		"message":    "2FA required",
+		"mfa_token":  token,
+		"expires_in": int(utils.MFATokenTTL.Seconds()),
+	}
+	if enabled {
+		body["mfa_required"] = true
+	} else {
+		// role wajib 2FA tapi belum enroll → setup dulu lewat /login/2fa/setup
+		body["mfa_setup_required"] = true
+	}
+	return body, true, nil
+}
+
+// challengeAccount mengambil account dari mfa_token
+func challengeAccount(c *gin.Context, mfaToken string) (models.UserAccount, bool) {
+	var account models.UserAccount
+
+	challenge, ok := utils.LookupMFAToken(mfaToken)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired 2FA token"})
+		return account, false
+	}
+
+	if until, locked := utils.LoginLockedUntil(challenge.Phone, c.ClientIP()); locked {
+		retryAfter := int(time.Until(until).Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many failed login attempts, try again later",
+			"retry_after": retryAfter,
+		})
+		return account, false
+	}
+
+	if err := config.DB.Preload("User").Preload("Role").First(&account, "id = ?", challenge.AccountID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired 2FA token"})
+		return account, false
+	}
+	if account.DeactivatedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account deactivated"})
+		return account, false
+	}
+	return account, true
+}
+
+// secondFactorFailed dihitung sebagai gagal login (lockout yang sama dengan password)
+func secondFactorFailed(c *gin.Context, account models.UserAccount) {
+	failures := utils.RegisterLoginFailure(account.Phone, c.ClientIP())
+	time.Sleep(utils.LoginDelay(failures))
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid 2FA code"})
+}
+
+// myTwoFactorLocked cek lockout login sebelum memeriksa kode di endpoint /me/2fa,
+// supaya kode tidak bisa ditebak lewat endpoint ini
+func myTwoFactorLocked(c *gin.Context, account models.UserAccount) bool {
+	until, locked := utils.LoginLockedUntil(account.Phone, c.ClientIP())
+	if !locked {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+	utils.SendErrorResponse(c, http.StatusTooManyRequests, "Too many failed attempts, try again later", nil)
+	return true
+}
+
+// mySecondFactorFailed kode salah di /me/2fa dihitung ke lockout yang sama dengan login
+func mySecondFactorFailed(c *gin.Context, account models.UserAccount, message string) {
+	failures := utils.RegisterLoginFailure(account.Phone, c.ClientIP())
+	time.Sleep(utils.LoginDelay(failures))
+	utils.SendErrorResponse(c, http.StatusBadRequest, message, nil)
+}
+
+// LoginTwoFactor godoc
+// @Summary      Login step 2 (2FA)
+// @Description  Tukar mfa_token + kode authenticator (atau recovery code) dengan access token
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        input body object{mfa_token=string,code=string,recovery_code=string} true "Token 2FA dan kode"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /login/2fa [post]
+func LoginTwoFactor(c *gin.Context) {
+	var input struct {
+		MFAToken     string `json:"mfa_token" binding:"required"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || (input.Code == "" && input.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	account, ok := challengeAccount(c, input.MFAToken)
+	if !ok {
+		return
+	}
+	if account.TOTPEnabledAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "2FA setup required"})
+		return
+	}
+
+	var err error
+	if input.Code != "" {
+		err = verifyAccountTOTP(config.DB, account, input.Code)
+	} else {
+		err = useRecoveryCode(account, input.RecoveryCode)
+	}
+	if err != nil {
+		secondFactorFailed(c, account)
+		return
+	}
+
+	utils.ConsumeMFAToken(input.MFAToken)
+	utils.ResetLoginFailures(account.Phone)
+	respondLogin(c, account, nil)
+}
+
+// SetupTwoFactorLogin godoc
+// @Summary      Enroll 2FA during login
+// @Description  Untuk role yang wajib 2FA tapi belum enroll: buat secret authenticator baru dari mfa_token
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        input body object{mfa_token=string} true "Token 2FA"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /login/2fa/setup [post]
+func SetupTwoFactorLogin(c *gin.Context) {
+	var input struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	account, ok := challengeAccount(c, input.MFAToken)
+	if !ok {
+		return
+	}
+
+	secret, uri, err := beginTOTPSetup(account)
+	if errors.Is(err, errTOTPAlreadyEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": "2FA already enabled"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to setup 2FA"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"secret": secret, "provisioning_uri": uri})
+}
+
+// EnableTwoFactorLogin godoc
+// @Summary      Confirm 2FA enrollment during login
+// @Description  Konfirmasi kode pertama dari authenticator, aktifkan 2FA, lalu login. Recovery code hanya ditampilkan sekali.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        input body object{mfa_token=string,code=string} true "Token 2FA dan kode"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Router       /login/2fa/enable [post]
+func EnableTwoFactorLogin(c *gin.Context) {
+	var input struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	account, ok := challengeAccount(c, input.MFAToken)
+	if !ok {
+		return
+	}
+
+	codes, err := enableTOTP(account, input.Code)
+	if errors.Is(err, errInvalidTOTPCode) || errors.Is(err, errTOTPNotEnabled) {
+		secondFactorFailed(c, account)
+		return
+	}
+	if errors.Is(err, errTOTPAlreadyEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": "2FA already enabled"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable 2FA"})
+		return
+	}
+
+	utils.ConsumeMFAToken(input.MFAToken)
+	utils.ResetLoginFailures(account.Phone)
+	respondLogin(c, account, gin.H{"recovery_codes": codes})
+}
+
+// GetMyTwoFactor godoc
+// @Summary      2FA status
+// @Tags         Me
+// @Produce      json
+// @Success      200 {object} utils.SuccessResponse
+// @Router       /me/2fa [get]
+func GetMyTwoFactor(c *gin.Context) {
+	account, ok := currentAccount(c)
+	if !ok {
+		return
+	}
+
+	var remaining int64
+	config.DB.Model(&models.RecoveryCode{}).Where("account_id = ? AND used_at IS NULL", account.ID).Count(&remaining)
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", gin.H{
+		"enabled":                  account.TOTPEnabledAt != nil,
+		"enabled_at":               account.TOTPEnabledAt,
+		"required":                 utils.TOTPRequiredForRole(account.Role.Name),
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// SetupMyTwoFactor godoc
+// @Summary      Start 2FA enrollment
+// @Description  Buat secret authenticator baru. 2FA baru aktif setelah dikonfirmasi di /me/2fa/enable.
+// @Tags         Me
+// @Produce      json
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      409 {object} utils.ErrorResponse
+// @Router       /me/2fa/setup [post]
+func SetupMyTwoFactor(c *gin.Context) {
+	account, ok := currentAccount(c)
+	if !ok {
+		return
+	}
+
+	secret, uri, err := beginTOTPSetup(account)
+	if errors.Is(err, errTOTPAlreadyEnabled) {
+		utils.SendErrorResponse(c, http.StatusConflict, "2FA already enabled", nil)
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to setup 2FA", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Scan the QR code, then confirm with a code", gin.H{
+		"secret":           secret,
+		"provisioning_uri": uri,
+	})
+}
+
+// EnableMyTwoFactor godoc
+// @Summary      Confirm 2FA enrollment
+// @Description  Aktifkan 2FA dengan kode dari authenticator. Recovery code hanya ditampilkan sekali.
+// @Tags         Me
+// @Accept       json
+// @Produce      json
+// @Param        input body object{code=string} true "Kode authenticator"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Router       /me/2fa/enable [post]
+func EnableMyTwoFactor(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	account, ok := currentAccount(c)
+	if !ok {
+		return
+	}
+
+	codes, err := enableTOTP(account, input.Code)
+	switch {
+	case errors.Is(err, errTOTPAlreadyEnabled):
+		utils.SendErrorResponse(c, http.StatusConflict, "2FA already enabled", nil)
+	case errors.Is(err, errTOTPNotEnabled):
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Call /me/2fa/setup first", nil)
+	case errors.Is(err, errInvalidTOTPCode):
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid 2FA code", nil)
+	case err != nil:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to enable 2FA", nil)
+	default:
+		utils.SendSuccessResponse(c, http.StatusOK, "2FA enabled", gin.H{"recovery_codes": codes})
+	}
+}
+
+// DisableMyTwoFactor godoc
+// @Summary      Disable 2FA
+// @Description  Matikan 2FA (tidak bisa untuk role yang wajib 2FA)
+// @Tags         Me
+// @Accept       json
+// @Produce      json
+// @Param        input body object{password=string,code=string} true "Password dan kode authenticator / recovery code"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      403 {object} utils.ErrorResponse
+// @Failure      429 {object} utils.ErrorResponse
+// @Router       /me/2fa/disable [post]
+func DisableMyTwoFactor(c *gin.Context) {
+	var input struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	account, ok := currentAccount(c)
+	if !ok {
+		return
+	}
+	if utils.TOTPRequiredForRole(account.Role.Name) {
+		utils.SendErrorResponse(c, http.StatusForbidden, "2FA is mandatory for your role", nil)
+		return
+	}
+	if account.TOTPEnabledAt == nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "2FA not enabled", nil)
+		return
+	}
+	if myTwoFactorLocked(c, account) {
+		return
+	}
+	if !utils.CheckPasswordHash(account.PasswordHash, input.Password) || !checkSecondFactor(account, input.Code) {
+		mySecondFactorFailed(c, account, "Invalid password or 2FA code")
+		return
+	}
+	utils.ResetLoginFailures(account.Phone)
+
+	if err := resetTOTP(account); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to disable 2FA", nil)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "2FA disabled", nil)
+}
+
+// RegenerateMyRecoveryCodes godoc
+// @Summary      Regenerate recovery codes
+// @Description  Buat recovery code baru, yang lama tidak berlaku lagi
+// @Tags         Me
+// @Accept       json
+// @Produce      json
+// @Param        input body object{code=string} true "Kode authenticator"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      429 {object} utils.ErrorResponse
+// @Router       /me/2fa/recovery-codes [post]
+func RegenerateMyRecoveryCodes(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	account, ok := currentAccount(c)
+	if !ok {
+		return
+	}
+	if account.TOTPEnabledAt == nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "2FA not enabled", nil)
+		return
+	}
+	if myTwoFactorLocked(c, account) {
+		return
+	}
+	if err := verifyAccountTOTP(config.DB, account, input.Code); err != nil {
+		mySecondFactorFailed(c, account, "Invalid 2FA code")
+		return
+	}
+	utils.ResetLoginFailures(account.Phone)
+
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, account)
+		return err
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to generate recovery codes", nil)
+		return
+	}
+	utils.SendSuccessResponse(c, http.StatusOK, "Recovery codes regenerated", gin.H{"recovery_codes": codes})
+}
+
+// ResetStaffTwoFactor godoc
+// @Summary      Reset 2FA of an account
+// @Description  Admin: hapus 2FA akun (misal HP hilang). Semua sesi akun dicabut, user enroll ulang saat login berikutnya.
+// @Tags         Staff
+// @Produce      json
+// @Param        id path string true "User Account ID"
+// @Success      200 {object} utils.SuccessResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Router       /admin/staff/{id}/2fa [delete]
+func ResetStaffTwoFactor(c *gin.Context) {
+	account, ok := findStaffAccount(c)
+	if !ok {
+		return
+	}
+
+	if err := resetTOTP(account); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to reset 2FA", nil)
+		return
+	}
+	if err := utils.RevokeUserTokens(account.UserID.String(), ""); err != nil {
+		log.Println("⚠️ Failed to revoke sessions after 2FA reset:", err)
+	}
+
+	log.Printf("🔐 2FA reset: account=%s by=%s", account.ID, c.GetString("user_id"))
+	utils.SendSuccessResponse(c, http.StatusOK, "2FA reset", gin.H{"id": account.ID})
+}
+
+// --- helpers ---
+
+// currentAccount mengambil account milik user yang login
+func currentAccount(c *gin.Context) (models.UserAccount, bool) {
+	var account models.UserAccount
+	if err := config.DB.Preload("User").Preload("Role").First(&account, "user_id = ?", currentUserID(c)).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "Account not found", nil)
+		return account, false
+	}
+	return account, true
+}
+
+// beginTOTPSetup menyimpan secret baru (belum aktif) dan mengembalikan provisioning URI
+func beginTOTPSetup(account models.UserAccount) (string, string, error) {
+	if account.TOTPEnabledAt != nil {
+		return "", "", errTOTPAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := config.DB.Model(&account).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+	}).Error; err != nil {
+		return "", "", err
+	}
+	return secret, utils.TOTPProvisioningURI(secret, account.Phone), nil
+}
+
+// enableTOTP mengaktifkan 2FA setelah kode pertama cocok, lalu membuat recovery code
+func enableTOTP(account models.UserAccount, code string) ([]string, error) {
+	if account.TOTPEnabledAt != nil {
+		return nil, errTOTPAlreadyEnabled
+	}
+	if account.TOTPSecret == "" {
+		return nil, errTOTPNotEnabled
+	}
+
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifyAccountTOTP(tx, account, code); err != nil {
+			return err
+		}
+		if err := tx.Model(&account).Update("totp_enabled_at", time.Now()).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, account)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔐 2FA enabled: account=%s", account.ID)
+	return codes, nil
+}
+
+// verifyAccountTOTP cek kode dan simpan periodenya, supaya kode yang sama tidak bisa dipakai 2x
+func verifyAccountTOTP(db *gorm.DB, account models.UserAccount, code string) error {
+	step, ok := utils.ValidateTOTP(account.TOTPSecret, code, utils.TOTPClock(), account.TOTPLastStep)
+	if !ok {
+		return errInvalidTOTPCode
+	}
+
+	res := db.Model(&models.UserAccount{}).
+		Where("id = ? AND totp_last_step < ?", account.ID, step).
+		Update("totp_last_step", step)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected != 1 {
+		return errInvalidTOTPCode
+	}
+	return nil
+}
+
+// useRecoveryCode menandai recovery code sebagai terpakai
+func useRecoveryCode(account models.UserAccount, code string) error {
+	res := config.DB.Model(&models.RecoveryCode{}).
+		Where("account_id = ? AND code_hash = ? AND used_at IS NULL", account.ID, utils.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected != 1 {
+		return errInvalidTOTPCode
+	}
+	log.Printf("🔐 Recovery code used: account=%s", account.ID)
+	return nil
+}
+
+// checkSecondFactor menerima kode authenticator atau recovery code
+func checkSecondFactor(account models.UserAccount, code string) bool {
+	if verifyAccountTOTP(config.DB, account, code) == nil {
+		return true
+	}
+	return useRecoveryCode(account, code) == nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, account models.UserAccount) ([]string, error) {
+	if err := tx.Where("account_id = ?", account.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]models.RecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		rows = append(rows, models.RecoveryCode{AccountID: account.ID, CodeHash: utils.HashRecoveryCode(code)})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func resetTOTP(account models.UserAccount) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&account).Updates(map[string]interface{}{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("account_id = ?", account.ID).Delete(&models.RecoveryCode{}).Error
+	})
+}
@@ -1,36 +1,226 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ary/go-api/config"
 	"github.com/ary/go-api/models"
 	"github.com/ary/go-api/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// UserListItem adalah baris di daftar customer (data user + ringkasan order)
+type UserListItem struct {
+	models.User
+	CustomerMetrics
+}
+
+// CustomerMetrics ringkasan order customer (dihitung, tidak disimpan)
+type CustomerMetrics struct {
+	OrderCount    int64      `json:"order_count"`
+	TotalQuantity int64      `json:"total_quantity"`
+	FirstOrderAt  *time.Time `json:"first_order_at"`
+	LastOrderAt   *time.Time `json:"last_order_at"`
+}
+
+// ringkasan order per user, kolomnya dipakai untuk select, filter dan sort daftar user
+const userOrderStatsJoin = `LEFT JOIN (
+	SELECT user_id, COUNT(*) AS order_count, COALESCE(SUM(quantity), 0) AS total_quantity,
+		MIN(created_at) AS first_order_at, MAX(created_at) AS last_order_at
+	FROM orders WHERE deleted_at IS NULL GROUP BY user_id
+) o ON o.user_id = users.id`
+
+const userOrderStatsSelect = `COALESCE(o.order_count, 0) AS order_count,
+	COALESCE(o.total_quantity, 0) AS total_quantity, o.first_order_at, o.last_order_at`
+
+// kolom yang boleh dipakai untuk ?sort=
+var userSortColumns = map[string]string{
+	"name":           "users.name",
+	"phone":          "users.phone",
+	"created_at":     "users.created_at",
+	"order_count":    "order_count",
+	"total_quantity": "total_quantity",
+	"first_order_at": "first_order_at",
+	"last_order_at":  "last_order_at",
+}
+
+// filter angka: ?min_orders=5&max_quantity=100
+var userMetricFilters = []struct {
+	param, column, op string
+}{
+	{"min_orders", "COALESCE(o.order_count, 0)", ">="},
+	{"max_orders", "COALESCE(o.order_count, 0)", "<="},
+	{"min_quantity", "COALESCE(o.total_quantity, 0)", ">="},
+	{"max_quantity", "COALESCE(o.total_quantity, 0)", "<="},
+}
+
+// filter tanggal (YYYY-MM-DD, batas "to" inklusif)
+var userDateFilters = []struct {
+	param, column string
+	to            bool
+}{
+	{"created_from", "users.created_at", false},
+	{"created_to", "users.created_at", true},
+	{"first_order_from", "o.first_order_at", false},
+	{"first_order_to", "o.first_order_at", true},
+	{"last_order_from", "o.last_order_at", false},
+	{"last_order_to", "o.last_order_at", true},
+}
+
 // @Summary Get all users
-// @Description Retrieve a list of all registered users
+// @Description Daftar customer dengan pencarian, filter, sorting dan pagination
 // @Tags Users
 // @Produce json
-// @Success 200 {array} models.User
+// @Param q            query string false "Cari nama, nomor HP atau email"
+// @Param regency      query string false "Filter kabupaten/kota"
+// @Param district     query string false "Filter kecamatan"
+// @Param is_active    query bool   false "Filter status aktif"
+// @Param created_from query string false "Terdaftar sejak (YYYY-MM-DD)"
+// @Param created_to   query string false "Terdaftar sampai (YYYY-MM-DD, inklusif)"
+// @Param tag          query string false "Nama tag, pisahkan dengan koma (user dengan salah satu tag)"
+// @Param min_orders   query int    false "Jumlah order minimal"
+// @Param max_orders   query int    false "Jumlah order maksimal"
+// @Param min_quantity query int    false "Total quantity minimal"
+// @Param max_quantity query int    false "Total quantity maksimal"
+// @Param first_order_from query string false "Order pertama sejak (YYYY-MM-DD)"
+// @Param first_order_to   query string false "Order pertama sampai (YYYY-MM-DD, inklusif)"
+// @Param last_order_from  query string false "Order terakhir sejak (YYYY-MM-DD)"
+// @Param last_order_to    query string false "Order terakhir sampai (YYYY-MM-DD, inklusif)"
+// @Param sort         query string false "name | phone | created_at | order_count | total_quantity | first_order_at | last_order_at (default created_at)"
+// @Param order        query string false "asc | desc (default desc)"
+// @Param page         query int    false "Page number (default is 1)"
+// @Param limit        query int    false "Number of items per page (default is 10, max 100)"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
-// @Failure 404 {object} utils.ErrorResponse
 // @Router /users [get]
 func GetUsers(c *gin.Context) {
-	var users []models.User
-	if err := config.DB.Find(&users).Error; err != nil {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset := (page - 1) * limit
+
+	query, err := filterUsers(config.DB.Model(&models.User{}).Joins(userOrderStatsJoin), c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to count users", nil)
+		return
+	}
+
+	sortColumn, ok := userSortColumns[c.DefaultQuery("sort", "created_at")]
+	if !ok {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid sort column", nil)
+		return
+	}
+	direction := "DESC NULLS LAST"
+	if strings.ToLower(c.Query("order")) == "asc" {
+		direction = "ASC NULLS FIRST"
+	}
+
+	users := []UserListItem{}
+	if err := query.
+		Select("users.*, " + userOrderStatsSelect).
+		Order(sortColumn + " " + direction).
+		Order("users.id").
+		Limit(limit).Offset(offset).
+		Scan(&users).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch users", nil)
 		return
 	}
-	if len(users) == 0 {
-		utils.SendErrorResponse(c, http.StatusNotFound, "No users found", nil)
+	if err := attachUserTags(users); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch user tags", nil)
 		return
 	}
-	utils.SendSuccessResponse(c, http.StatusOK, "Success", users)
+
+	response := gin.H{
+		"data":       users,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+		"totalPages": int(math.Ceil(float64(total) / float64(limit))),
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", response)
+}
+
+// filterUsers menerapkan filter dari query string ke daftar user.
+// db harus sudah di-join dengan userOrderStatsJoin (alias o).
+func filterUsers(db *gorm.DB, c *gin.Context) (*gorm.DB, error) {
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + utils.EscapeLike(q) + "%"
+		phoneLike := like
+		if phone, err := utils.NormalizePhone(q); err == nil {
+			phoneLike = "%" + utils.EscapeLike(phone) + "%" // nomor disimpan dalam format E.164
+		}
+		db = db.Where("users.name ILIKE ? OR users.phone ILIKE ? OR users.email ILIKE ?", like, phoneLike, like)
+	}
+	if regency := c.Query("regency"); regency != "" {
+		db = db.Where("users.regency ILIKE ?", utils.EscapeLike(regency))
+	}
+	if district := c.Query("district"); district != "" {
+		db = db.Where("users.district ILIKE ?", utils.EscapeLike(district))
+	}
+	if active := c.Query("is_active"); active != "" {
+		isActive, err := strconv.ParseBool(active)
+		if err != nil {
+			return nil, errors.New("is_active must be true or false")
+		}
+		db = db.Where("users.is_active = ?", isActive)
+	}
+	if tags := splitQueryList(c.Query("tag")); len(tags) > 0 {
+		db = db.Where(`EXISTS (
+			SELECT 1 FROM user_tags ut JOIN customer_tags t ON t.id = ut.customer_tag_id
+			WHERE ut.user_id = users.id AND t.name IN ?)`, tags)
+	}
+	for _, f := range userMetricFilters {
+		value := c.Query(f.param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return nil, errors.New(f.param + " must be a non-negative number")
+		}
+		db = db.Where(f.column+" "+f.op+" ?", n)
+	}
+	for _, f := range userDateFilters {
+		value := c.Query(f.param)
+		if value == "" {
+			continue
+		}
+		t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return nil, errors.New(f.param + " must be YYYY-MM-DD")
+		}
+		if f.to {
+			db = db.Where(f.column+" < ?", t.AddDate(0, 0, 1))
+		} else {
+			db = db.Where(f.column+" >= ?", t)
+		}
+	}
+	// session baru supaya query bisa dipakai untuk Count dan Find
+	return db.Session(&gorm.Session{}), nil
 }
 
 // @Summary Get user by ID
@@ -51,35 +241,9 @@ func GetUserByID(c *gin.Context) {
 		return
 	}
 
-	// Generate token untuk user ini
-	token, err := utils.GenerateJWT(
-		user.ID.String(),
-		user.Phone,
-		user.Name,
-		user.Email,
-		user.Address,
-		user.Regency,
-		user.District,
-		strconv.FormatFloat(user.Lat, 'f', -1, 64),
-		strconv.FormatFloat(user.Lang, 'f', -1, 64),
-		user.PhotoUrl,
-		"user",
-	)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to generate token", nil)
-		return
-	}
-
-	// Buat response
-	response := struct {
-		User  models.User `json:"user"`
-		Token string      `json:"token"`
-	}{
-		User:  user,
-		Token: token,
-	}
-
-	utils.SendSuccessResponse(c, http.StatusOK, "Success", response)
+	// Tidak ada token di sini: token baru hanya lewat login / refresh, supaya
+	// sesi, logout dan jejak impersonation tidak bisa dilewati
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", gin.H{"user": user})
 }
 
 // func CreateUser(c *gin.Context) {
@@ -135,6 +299,10 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	if !normalizeInputPhone(c, &user.Phone) {
+		return
+	}
+
 	// Generate UUID jika tidak dikirim dari JSON
 	user.ID = uuid.New()
 
@@ -156,6 +324,13 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	saveUserProfile(c, &user, true)
+}
+
+// saveUserProfile membaca form-data profil (termasuk foto) lalu menyimpan user.
+// Dipakai oleh PATCH /users/:id dan PATCH /me/profile. allowPhoneChange=false menolak
+// nomor HP yang berbeda dari nomor sekarang (nomor login hanya berubah lewat verifikasi OTP).
+func saveUserProfile(c *gin.Context, user *models.User, allowPhoneChange bool) {
 	// Parse multipart form (supaya PostForm dan FormFile bisa dibaca)
 	if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
 		fmt.Println("Failed to parse multipart form:", err)
@@ -201,6 +376,13 @@ func UpdateUser(c *gin.Context) {
 	if email != "" {
 		user.Email = email
 	}
+	if !normalizeInputPhone(c, &phone) {
+		return
+	}
+	if phone != "" && phone != user.Phone && !allowPhoneChange {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Phone number cannot be changed from profile", nil)
+		return
+	}
 	if phone != "" {
 		user.Phone = phone
 	}
@@ -232,7 +414,7 @@ func UpdateUser(c *gin.Context) {
 	}
 
 	// Simpan ke DB
-	if err := config.DB.Save(&user).Error; err != nil {
+	if err := config.DB.Save(user).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update user", nil)
 		return
 	}
@@ -265,3 +447,18 @@ func DeleteUser(c *gin.Context) {
 
 	utils.SendSuccessResponse(c, http.StatusOK, "User deleted successfully", nil)
 }
+
+// normalizeInputPhone mengubah nomor HP dari request ke format E.164.
+// Nomor kosong dibiarkan (field opsional), nomor tidak valid langsung dibalas 400.
+func normalizeInputPhone(c *gin.Context, phone *string) bool {
+	if *phone == "" {
+		return true
+	}
+	normalized, err := utils.NormalizePhone(*phone)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid phone number", nil)
+		return false
+	}
+	*phone = normalized
+	return true
+}
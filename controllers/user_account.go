@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 
 	"github.com/ary/go-api/config"
@@ -8,24 +10,32 @@ import (
 	"github.com/ary/go-api/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var errAccountExists = errors.New("account already exists")
+
 // @Summary Create a new user account
-// @Description Create a new user account with name, password, and user ID
+// @Description Create a new customer account for an existing user. Nomor HP diambil dari data user
+// @Description dan harus dibuktikan dengan kode OTP dari /otp/request.
+// @Description Role selalu customer, akun staff dibuat lewat /admin/staff/invite.
 // @Tags User Accounts
 // @Accept json
 // @Produce json
-// @Param user_account body models.UserAccount true "User Account Input"
+// @Param user_account body object{user_id=string,password=string,code=string} true "User Account Input"
 // @Success 201 {object} models.UserAccount
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 429 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /user-accounts [post]
 func CreateUserAccount(c *gin.Context) {
 	var input struct {
-		Phone    string    `json:"phone" binding:"required"`
-		Password string    `json:"password" binding:"required"`
-		Role     string    `json:"role" binding:"required"`
 		UserID   uuid.UUID `json:"user_id" binding:"required"`
+		Password string    `json:"password" binding:"required"`
+		Code     string    `json:"code" binding:"required"` // OTP yang dikirim ke nomor HP user
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -33,24 +43,72 @@ func CreateUserAccount(c *gin.Context) {
 		return
 	}
 
+	// response sama dengan OTP salah supaya user_id tidak bisa ditebak
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", input.UserID).Error; err != nil || user.Phone == "" {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Invalid or expired code", nil)
+		return
+	}
+
+	if err := utils.ValidatePassword(input.Password, user.Phone); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// bukti nomor HP milik pemohon
+	switch err := consumeOTP(user.Phone, input.Code); {
+	case errors.Is(err, errOTPTooManyAttempts):
+		utils.SendErrorResponse(c, http.StatusTooManyRequests, "Too many attempts, request a new code", nil)
+		return
+	case errors.Is(err, errOTPInvalid):
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Invalid or expired code", nil)
+		return
+	case err != nil:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to verify OTP", nil)
+		return
+	}
+
 	// Hash password
-	passwordHash := utils.HashPassword(input.Password)
+	passwordHash, err := utils.HashPassword(input.Password)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create account", nil)
+		return
+	}
 
-	// Cek apakah user_id sudah punya account
-	var existing models.UserAccount
-	if err := config.DB.Where("user_id = ?", input.UserID).First(&existing).Error; err == nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "Account already exists", nil)
+	role, err := findRole(config.DB, utils.RoleCustomer)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create account", nil)
 		return
 	}
 
 	userAccount := models.UserAccount{
-		Phone:        input.Phone,
+		Phone:        user.Phone,
 		PasswordHash: passwordHash,
-		Role:         input.Role,
-		UserID:       input.UserID,
+		RoleID:       role.ID,
+		UserID:       user.ID,
 	}
 
-	if err := config.DB.Create(&userAccount).Error; err != nil {
+	// 1 user 1 account, dan 1 nomor HP 1 account (Login mencari account lewat nomor HP)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", user.ID).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.UserAccount{}).
+			Where("user_id = ? OR phone = ?", user.ID, user.Phone).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errAccountExists
+		}
+		return tx.Create(&userAccount).Error
+	})
+	if errors.Is(err, errAccountExists) {
+		utils.SendErrorResponse(c, http.StatusConflict, "Account already exists", nil)
+		return
+	}
+	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create account", nil)
 		return
 	}
@@ -59,23 +117,22 @@ func CreateUserAccount(c *gin.Context) {
 }
 
 // @Summary Update user account password
-// @Description Update the password for a user account
+// @Description Ganti password akun yang sedang login. Password lama wajib diisi.
+// @Description Untuk lupa password / aktivasi pertama gunakan /password/forgot dan /password/reset.
 // @Tags User Accounts
 // @Accept json
 // @Produce json
-// @Param input body object{user_account_id=string,old_password=string,new_password=string} true "Password Update Input"
+// @Param input body object{old_password=string,new_password=string} true "Password Update Input"
 // @Success 200 {string} string "Password berhasil diperbarui"
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 401 {object} utils.ErrorResponse
 // @Failure 404 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
-// @Router /user-accounts/password [patch]
+// @Router /user-accounts/update-password [patch]
 func UpdatePasswordUserAccount(c *gin.Context) {
 	var input struct {
-		UserAccountID uuid.UUID `json:"user_account_id" binding:"required"`
-		OldPassword   string    `json:"old_password"`
-		NewPassword   string    `json:"new_password" binding:"required"`
-		IsActive      bool      `json:"is_active"`
+		OldPassword string `json:"old_password" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -83,47 +140,55 @@ func UpdatePasswordUserAccount(c *gin.Context) {
 		return
 	}
 
+	// akun selalu diambil dari token, bukan dari body
 	var account models.UserAccount
-	if err := config.DB.Preload("User").Where("user_id = ?", input.UserAccountID).First(&account).Error; err != nil {
+	if err := config.DB.Where("user_id = ?", c.GetString("user_id")).First(&account).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusNotFound, "Akun tidak ditemukan", nil)
 		return
 	}
 
-	// Jika IsActive true → wajib validasi old password
-	if input.IsActive {
-		if input.OldPassword == "" {
-			utils.SendErrorResponse(c, http.StatusBadRequest, "Password lama diperlukan", nil)
-			return
-		}
-		if !utils.CheckPasswordHash(account.PasswordHash, input.OldPassword) {
-			utils.SendErrorResponse(c, http.StatusUnauthorized, "Password lama salah", nil)
-			return
-		}
+	if !utils.CheckPasswordHash(account.PasswordHash, input.OldPassword) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "Password lama salah", nil)
+		return
 	}
 
-	newHashed := utils.HashPassword(input.NewPassword)
+	if err := utils.ValidatePassword(input.NewPassword, account.Phone); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	newHashed, err := utils.HashPassword(input.NewPassword)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal update password", nil)
+		return
+	}
 	tx := config.DB.Begin()
 
 	// ✅ Hanya update password_hash
 	if err := tx.Model(&models.UserAccount{}).
-		Where("user_id = ?", input.UserAccountID).
+		Where("id = ?", account.ID).
 		Update("password_hash", newHashed).Error; err != nil {
 		tx.Rollback()
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal update password", nil)
 		return
 	}
 
-	// Jika user belum aktif → aktifkan
-	if !account.User.IsActive {
-		if err := tx.Model(&models.User{}).
-			Where("id = ?", account.User.ID).
-			Update("is_active", true).Error; err != nil {
-			tx.Rollback()
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal mengaktifkan user", nil)
-			return
-		}
+	if err := logPasswordChange(tx, c, account.ID, "change"); err != nil {
+		tx.Rollback()
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Gagal update password", nil)
+		return
 	}
 
 	tx.Commit()
+
+	// sesi lain (device lain) dicabut, sesi sekarang tetap jalan
+	var sessionID string
+	if claims := currentClaims(c); claims != nil {
+		sessionID = claims.SessionID
+	}
+	if err := utils.RevokeUserTokens(account.UserID.String(), sessionID); err != nil {
+		log.Println("⚠️ Failed to revoke other sessions:", err)
+	}
+
 	utils.SendSuccessResponse(c, http.StatusOK, "Password berhasil diperbarui", nil)
 }
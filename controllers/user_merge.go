@@ -0,0 +1,585 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Guest checkout bisa membuat beberapa user untuk orang yang sama.
+// Merge memindahkan order, notifikasi, alamat, akun, keanggotaan company serta tag & catatan CRM
+// ke 1 user yang dipertahankan.
+
+const maxMergeSources = 20
+
+var (
+	errMergeDryRun       = errors.New("dry run")
+	errMergeUserNotFound = errors.New("user not found")
+	errMergeStaffAccount = errors.New("staff account cannot be merged")
+)
+
+// MergeSource ringkasan data yang akan dipindah dari 1 user duplikat
+type MergeSource struct {
+	UserID        uuid.UUID  `json:"user_id"`
+	Name          string     `json:"name"`
+	Phone         string     `json:"phone"`
+	Orders        int64      `json:"orders"`
+	Notifications int64      `json:"notifications"`
+	Addresses     int64      `json:"addresses"`
+	Notes         int64      `json:"notes"`
+	Companies     int64      `json:"companies"`
+	AccountID     *uuid.UUID `json:"account_id"`
+}
+
+// MergePlan hasil preview merge (dry run) sekaligus isi audit log-nya
+type MergePlan struct {
+	TargetID       uuid.UUID         `json:"target_id"`
+	Sources        []MergeSource     `json:"sources"`
+	Orders         int64             `json:"orders"`
+	Notifications  int64             `json:"notifications"`
+	Addresses      int64             `json:"addresses"`
+	Notes          int64             `json:"notes"`
+	Companies      int64             `json:"companies"`
+	KeepAccountID  *uuid.UUID        `json:"keep_account_id"`  // akun yang dipakai user hasil merge
+	DropAccountIDs []uuid.UUID       `json:"drop_account_ids"` // akun lain dihapus (soft delete)
+	FilledFields   map[string]string `json:"filled_fields"`    // field kosong di target yang diisi dari duplikat
+}
+
+// MergeUsers godoc
+// @Summary      Merge duplicate customers
+// @Description  Pindahkan order, notifikasi, alamat, akun, company dan data CRM dari user duplikat (source_ids) ke user {id}, lalu hapus duplikatnya.
+// @Description  Semua dalam 1 transaksi dan dicatat di audit log. dry_run=true hanya menampilkan apa yang akan dipindah.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "User ID yang dipertahankan"
+// @Param        input body object{source_ids=[]string,dry_run=bool} true "User duplikat"
+// @Success      200 {object} MergePlan
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      404 {object} utils.ErrorResponse
+// @Failure      409 {object} utils.ErrorResponse
+// @Router       /admin/users/{id}/merge [post]
+func MergeUsers(c *gin.Context) {
+	var input struct {
+		SourceIDs []string `json:"source_ids" binding:"required"`
+		DryRun    bool     `json:"dry_run"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "source_ids is required", nil)
+		return
+	}
+
+	targetID := utils.ParseUUID(c.Param("id"))
+	if targetID == uuid.Nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+	sourceIDs, err := parseMergeSources(targetID, input.SourceIDs)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var plan MergePlan
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		plan, err = planMerge(tx, targetID, sourceIDs)
+		if err != nil {
+			return err
+		}
+		if input.DryRun {
+			return errMergeDryRun // rollback, tidak ada yang berubah
+		}
+		if err := executeMerge(tx, plan); err != nil {
+			return err
+		}
+
+		entry := utils.AuditFromContext(c, "users.merge")
+		entry.SubjectID = targetID
+		entry.StatusCode = http.StatusOK
+		return utils.CreateAuditLog(tx, entry, plan)
+	})
+
+	switch {
+	case errors.Is(err, errMergeDryRun):
+		utils.SendSuccessResponse(c, http.StatusOK, "Dry run, nothing changed", plan)
+		return
+	case errors.Is(err, errMergeUserNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "User not found", nil)
+		return
+	case errors.Is(err, errMergeStaffAccount):
+		utils.SendErrorResponse(c, http.StatusConflict, "Users with staff accounts cannot be merged", nil)
+		return
+	case err != nil:
+		log.Println("❌ Failed to merge users:", err)
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to merge users", nil)
+		return
+	}
+
+	// token lama user duplikat membawa user_id yang sudah dihapus
+	for _, id := range sourceIDs {
+		if err := utils.RevokeUserTokens(id.String(), ""); err != nil {
+			log.Printf("⚠️ Failed to revoke tokens of merged user %s: %v", id, err)
+		}
+	}
+
+	log.Printf("🔀 Users merged into %s: %v by=%s", targetID, sourceIDs, c.GetString("user_id"))
+	utils.SendSuccessResponse(c, http.StatusOK, "Users merged", plan)
+}
+
+func parseMergeSources(targetID uuid.UUID, raw []string) ([]uuid.UUID, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("source_ids is required")
+	}
+	if len(raw) > maxMergeSources {
+		return nil, errors.New("too many source_ids (max " + strconv.Itoa(maxMergeSources) + ")")
+	}
+
+	seen := map[uuid.UUID]bool{}
+	var ids []uuid.UUID
+	for _, s := range raw {
+		id := utils.ParseUUID(s)
+		if id == uuid.Nil {
+			return nil, errors.New("invalid source id: " + s)
+		}
+		if id == targetID {
+			return nil, errors.New("source_ids must not contain the target user")
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// planMerge mengunci user yang terlibat lalu menghitung apa saja yang akan dipindah
+func planMerge(tx *gorm.DB, targetID uuid.UUID, sourceIDs []uuid.UUID) (MergePlan, error) {
+	plan := MergePlan{TargetID: targetID, FilledFields: map[string]string{}}
+
+	var target models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&target, "id = ?", targetID).Error; err != nil {
+		return plan, errMergeUserNotFound
+	}
+	var sources []models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", sourceIDs).Order("created_at ASC").Find(&sources).Error; err != nil {
+		return plan, err
+	}
+	if len(sources) != len(sourceIDs) {
+		return plan, errMergeUserNotFound
+	}
+
+	var accounts []models.UserAccount
+	if err := tx.Preload("Role").
+		Where("user_id IN ?", append([]uuid.UUID{targetID}, sourceIDs...)).
+		Order("updated_at DESC").Find(&accounts).Error; err != nil {
+		return plan, err
+	}
+	accountByUser := map[uuid.UUID]models.UserAccount{}
+	for _, account := range accounts {
+		// akun staff tidak ikut merge customer supaya hak aksesnya tidak berpindah diam-diam
+		if account.Role.IsStaff && len(accounts) > 1 {
+			return plan, errMergeStaffAccount
+		}
+		if _, ok := accountByUser[account.UserID]; !ok {
+			accountByUser[account.UserID] = account
+		}
+	}
+
+	// akun target dipakai kalau ada, kalau tidak akun duplikat yang terakhir dipakai
+	if account, ok := accountByUser[targetID]; ok {
+		plan.KeepAccountID = &account.ID
+	} else if len(accounts) > 0 {
+		plan.KeepAccountID = &accounts[0].ID
+	}
+	for _, account := range accounts {
+		if plan.KeepAccountID == nil || account.ID != *plan.KeepAccountID {
+			plan.DropAccountIDs = append(plan.DropAccountIDs, account.ID)
+		}
+	}
+
+	for _, source := range sources {
+		item := MergeSource{UserID: source.ID, Name: source.Name, Phone: source.Phone}
+		counts := []struct {
+			model interface{}
+			dest  *int64
+		}{
+			{&models.Order{}, &item.Orders},
+			{&models.Notification{}, &item.Notifications},
+			{&models.Address{}, &item.Addresses},
+			{&models.CustomerNote{}, &item.Notes},
+			{&models.CompanyMember{}, &item.Companies},
+		}
+		for _, count := range counts {
+			if err := tx.Model(count.model).Where("user_id = ?", source.ID).Count(count.dest).Error; err != nil {
+				return plan, err
+			}
+		}
+		if account, ok := accountByUser[source.ID]; ok {
+			item.AccountID = &account.ID
+		}
+
+		plan.Orders += item.Orders
+		plan.Notifications += item.Notifications
+		plan.Addresses += item.Addresses
+		plan.Notes += item.Notes
+		plan.Companies += item.Companies
+		plan.Sources = append(plan.Sources, item)
+
+		fillMergeField(plan.FilledFields, "name", target.Name, source.Name)
+		fillMergeField(plan.FilledFields, "email", target.Email, source.Email)
+		fillMergeField(plan.FilledFields, "phone", target.Phone, source.Phone)
+		fillMergeField(plan.FilledFields, "photo_url", target.PhotoUrl, source.PhotoUrl)
+	}
+	return plan, nil
+}
+
+// fillMergeField mengisi field target yang kosong dengan nilai duplikat pertama yang terisi
+func fillMergeField(fields map[string]string, column, current, candidate string) {
+	if strings.TrimSpace(current) != "" || candidate == "" {
+		return
+	}
+	if _, ok := fields[column]; !ok {
+		fields[column] = candidate
+	}
+}
+
+func executeMerge(tx *gorm.DB, plan MergePlan) error {
+	sourceIDs := make([]uuid.UUID, 0, len(plan.Sources))
+	for _, source := range plan.Sources {
+		sourceIDs = append(sourceIDs, source.UserID)
+	}
+
+	// kosongkan nomor HP duplikat dulu (kolom unique) supaya bisa dipakai target
+	if err := tx.Model(&models.User{}).Where("id IN ?", sourceIDs).
+		Update("phone", gorm.Expr("NULL")).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.Order{}).Where("user_id IN ?", sourceIDs).
+		Update("user_id", plan.TargetID).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Notification{}).Where("user_id IN ?", sourceIDs).
+		Update("user_id", plan.TargetID).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.CustomerNote{}).Where("user_id IN ?", sourceIDs).
+		Update("user_id", plan.TargetID).Error; err != nil {
+		return err
+	}
+	// tag digabung, tag yang sudah dimiliki target tidak diduplikasi
+	if err := tx.Exec(`INSERT INTO user_tags (user_id, customer_tag_id)
+		SELECT DISTINCT ?::uuid, customer_tag_id FROM user_tags WHERE user_id IN ?
+		ON CONFLICT DO NOTHING`, plan.TargetID, sourceIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM user_tags WHERE user_id IN ?", sourceIDs).Error; err != nil {
+		return err
+	}
+
+	if err := mergeCompanyMemberships(tx, plan.TargetID, sourceIDs); err != nil {
+		return err
+	}
+
+	// alamat pindah sebagai alamat biasa, default tetap milik target
+	if err := tx.Model(&models.Address{}).Where("user_id IN ?", sourceIDs).
+		Updates(map[string]interface{}{"user_id": plan.TargetID, "is_default": false}).Error; err != nil {
+		return err
+	}
+	var defaults int64
+	if err := tx.Model(&models.Address{}).Where("user_id = ? AND is_default", plan.TargetID).Count(&defaults).Error; err != nil {
+		return err
+	}
+	if defaults == 0 {
+		var first models.Address
+		err := tx.Where("user_id = ?", plan.TargetID).Order("created_at ASC").First(&first).Error
+		if err == nil {
+			if err := setDefaultAddress(tx, first); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	if len(plan.FilledFields) > 0 {
+		updates := map[string]interface{}{}
+		for column, value := range plan.FilledFields {
+			updates[column] = value
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", plan.TargetID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(plan.DropAccountIDs) > 0 {
+		if err := tx.Delete(&models.UserAccount{}, "id IN ?", plan.DropAccountIDs).Error; err != nil {
+			return err
+		}
+	}
+	if plan.KeepAccountID != nil {
+		var target models.User
+		if err := tx.First(&target, "id = ?", plan.TargetID).Error; err != nil {
+			return err
+		}
+		updates := map[string]interface{}{"user_id": plan.TargetID}
+		if target.Phone != "" {
+			updates["phone"] = target.Phone // nomor login ikut user hasil merge
+		}
+		if err := tx.Model(&models.UserAccount{}).Where("id = ?", *plan.KeepAccountID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Delete(&models.User{}, "id IN ?", sourceIDs).Error
+}
+
+// mergeCompanyMemberships memindahkan keanggotaan company. Kalau target sudah
+// anggota company yang sama, keanggotaan duplikat dihapus dan role owner dipertahankan.
+func mergeCompanyMemberships(tx *gorm.DB, targetID uuid.UUID, sourceIDs []uuid.UUID) error {
+	var existing, memberships []models.CompanyMember
+	if err := tx.Where("user_id = ?", targetID).Find(&existing).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id IN ?", sourceIDs).Order("created_at ASC").Find(&memberships).Error; err != nil {
+		return err
+	}
+
+	byCompany := map[uuid.UUID]models.CompanyMember{}
+	for _, m := range existing {
+		byCompany[m.CompanyID] = m
+	}
+	for _, m := range memberships {
+		current, ok := byCompany[m.CompanyID]
+		if !ok {
+			if err := tx.Model(&m).Update("user_id", targetID).Error; err != nil {
+				return err
+			}
+			m.UserID = targetID
+			byCompany[m.CompanyID] = m
+			continue
+		}
+		// undangan yang belum diterima tidak menaikkan role / status keanggotaan target
+		if m.Status == companyMemberActive {
+			updates := map[string]interface{}{}
+			if current.Status != companyMemberActive {
+				updates["status"] = companyMemberActive
+				current.Status = companyMemberActive
+			}
+			if m.Role == companyRoleOwner && current.Role != companyRoleOwner {
+				updates["role"] = companyRoleOwner
+				current.Role = companyRoleOwner
+			}
+			if len(updates) > 0 {
+				if err := tx.Model(&current).Updates(updates).Error; err != nil {
+					return err
+				}
+				byCompany[m.CompanyID] = current
+			}
+		}
+		if err := tx.Delete(&m).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DuplicateSuggestion pasangan user yang kemungkinan orang yang sama
+type DuplicateSuggestion struct {
+	Score   float64       `json:"score"`
+	Reasons []string      `json:"reasons"` // phone, email, name, address
+	Users   []models.User `json:"users"`
+}
+
+const (
+	duplicateNameThreshold    = 0.8
+	duplicateAddressThreshold = 0.7
+	// blok nama yang sangat umum (mis. "muhammad") dilewati supaya tidak O(n²)
+	duplicateMaxBlockSize = 300
+)
+
+// duplicateBlocksSQL mengelompokkan user per kunci blocking: nomor HP (hanya digit, 0 / 620 jadi 62),
+// email, kata pertama dan kata terakhir nama. Hanya blok berisi 2..duplicateMaxBlockSize user.
+const duplicateBlocksSQL = `WITH active AS (
+	SELECT id, phone, email, trim(regexp_replace(lower(name), '[^[:alnum:]]+', ' ', 'g')) AS name
+	FROM users WHERE deleted_at IS NULL AND erased_at IS NULL
+), keys AS (
+	SELECT id, 'phone:' || regexp_replace(regexp_replace(regexp_replace(regexp_replace(
+		phone, '[^0-9]', '', 'g'), '^00', ''), '^0', '62'), '^620', '62') AS key
+	FROM active WHERE coalesce(phone, '') <> ''
+	UNION ALL
+	SELECT id, 'email:' || lower(trim(email)) FROM active WHERE trim(coalesce(email, '')) <> ''
+	UNION ALL
+	SELECT id, 'first:' || split_part(name, ' ', 1) FROM active WHERE name <> ''
+	UNION ALL
+	SELECT id, 'last:' || regexp_replace(name, '^.* ', '') FROM active WHERE name <> ''
+)
+SELECT key, string_agg(id::text, ',' ORDER BY id) AS ids FROM keys
+GROUP BY key HAVING COUNT(*) BETWEEN 2 AND ?`
+
+// GetDuplicateUsers godoc
+// @Summary      Suggest duplicate customers
+// @Description  Saran pasangan user yang kemungkinan duplikat berdasarkan nomor HP, email, kemiripan nama dan alamat.
+// @Description  User dikelompokkan di database per nomor HP, email dan kata pertama / terakhir nama; pagination per kelompok, saran di tiap halaman diurutkan dari skor tertinggi.
+// @Tags         Admin
+// @Produce      json
+// @Param        page      query int    false "Page number (default is 1)"
+// @Param        limit     query int    false "Jumlah kelompok per halaman (default 20, max 100)"
+// @Param        min_score query number false "Skor minimal 0..1 (default 0.75)"
+// @Success      200 {object} utils.SuccessResponse{data=[]DuplicateSuggestion}
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /admin/users/duplicates [get]
+func GetDuplicateUsers(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	minScore, err := strconv.ParseFloat(c.DefaultQuery("min_score", "0.75"), 64)
+	if err != nil || minScore < 0 || minScore > 1 {
+		minScore = 0.75
+	}
+
+	var total int64
+	if err := config.DB.Raw("SELECT COUNT(*) FROM ("+duplicateBlocksSQL+") blocks", duplicateMaxBlockSize).
+		Scan(&total).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to count duplicate users", nil)
+		return
+	}
+
+	// kelompok nomor HP & email duluan, karena paling mungkin benar-benar duplikat
+	var blocks []struct {
+		Key string `gorm:"column:key"`
+		IDs string `gorm:"column:ids"`
+	}
+	if err := config.DB.Raw(duplicateBlocksSQL+`
+		ORDER BY (key LIKE 'phone:%' OR key LIKE 'email:%') DESC, key
+		LIMIT ? OFFSET ?`, duplicateMaxBlockSize, limit, (page-1)*limit).
+		Scan(&blocks).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get duplicate users", nil)
+		return
+	}
+
+	var ids []uuid.UUID
+	for _, block := range blocks {
+		for _, id := range strings.Split(block.IDs, ",") {
+			ids = append(ids, utils.ParseUUID(id))
+		}
+	}
+	var users []models.User
+	if len(ids) > 0 {
+		if err := config.DB.Where("id IN ?", ids).Find(&users).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to get users", nil)
+			return
+		}
+	}
+
+	index := make(map[uuid.UUID]int, len(users))
+	for i, u := range users {
+		index[u.ID] = i
+	}
+	groups := make([][]int, 0, len(blocks))
+	for _, block := range blocks {
+		var group []int
+		for _, id := range strings.Split(block.IDs, ",") {
+			if i, ok := index[utils.ParseUUID(id)]; ok {
+				group = append(group, i)
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Success", gin.H{
+		"data":       suggestDuplicates(users, groups, minScore),
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+		"totalPages": int(math.Ceil(float64(total) / float64(limit))),
+	})
+}
+
+// suggestDuplicates menilai setiap pasangan user dalam blok yang sama (index ke users)
+func suggestDuplicates(users []models.User, blocks [][]int, minScore float64) []DuplicateSuggestion {
+	type pair struct{ a, b int }
+	candidates := map[pair]bool{}
+	for _, block := range blocks {
+		for i := 0; i < len(block); i++ {
+			for j := i + 1; j < len(block); j++ {
+				a, b := block[i], block[j]
+				if users[a].CreatedAt.After(users[b].CreatedAt) {
+					a, b = b, a
+				}
+				candidates[pair{a, b}] = true
+			}
+		}
+	}
+
+	suggestions := []DuplicateSuggestion{}
+	for p := range candidates {
+		a, b := users[p.a], users[p.b]
+
+		var reasons []string
+		samePhone := a.Phone != "" && utils.NormalizePhoneOrRaw(a.Phone) == utils.NormalizePhoneOrRaw(b.Phone)
+		if samePhone {
+			reasons = append(reasons, "phone")
+		}
+		sameEmail := strings.TrimSpace(a.Email) != "" && strings.EqualFold(strings.TrimSpace(a.Email), strings.TrimSpace(b.Email))
+		if sameEmail {
+			reasons = append(reasons, "email")
+		}
+		nameScore := utils.TextSimilarity(a.Name, b.Name)
+		if nameScore >= duplicateNameThreshold {
+			reasons = append(reasons, "name")
+		}
+		addressScore := utils.TextSimilarity(userAddressText(a), userAddressText(b))
+		if addressScore >= duplicateAddressThreshold {
+			reasons = append(reasons, "address")
+		}
+
+		score := 0.6*nameScore + 0.4*addressScore
+		if samePhone || sameEmail {
+			score = 1
+		}
+		if score < minScore || len(reasons) == 0 {
+			continue
+		}
+		suggestions = append(suggestions, DuplicateSuggestion{
+			Score:   float64(int(score*100)) / 100,
+			Reasons: reasons,
+			Users:   []models.User{a, b},
+		})
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Users[1].CreatedAt.After(suggestions[j].Users[1].CreatedAt)
+	})
+	return suggestions
+}
+
+func userAddressText(u models.User) string {
+	return strings.Join([]string{u.Address, u.District, u.Regency}, " ")
+}
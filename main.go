@@ -1,7 +1,11 @@
 package main
 
 import (
+	"log"
+	"time"
+
 	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/otp"
 	// "github.com/ary/go-api/middlewares"
 	"github.com/ary/go-api/routes"
 	"github.com/ary/go-api/utils"
@@ -25,8 +29,26 @@ func main() {
 	utils.SetupMonitoring(r) // /metrics
 	// r.Use(cors.Default())
 	config.ConnectDB()
+	if err := config.SeedRoles(utils.DefaultRoles(), utils.RoleCustomer); err != nil {
+		log.Println("❌ Failed to seed roles:", err)
+	}
+	if err := config.BackfillAddresses(); err != nil {
+		log.Println("❌ Failed to backfill addresses:", err)
+	}
+	if err := config.SetupProductSearch(); err != nil {
+		log.Println("⚠️ Product search falls back to ILIKE:", err)
+	}
 	config.ConnectRedis()
-	go ws.H.Run() // ⬅️ jalanin hub websocket
+	if err := utils.LoadJWTKeys(); err != nil {
+		log.Fatal("❌ Failed to load JWT keys:", err)
+	}
+	if err := otp.LoadSecret(config.DevMode()); err != nil {
+		log.Fatal("❌ Failed to load OTP secret:", err)
+	}
+	go ws.H.Run()                           // ⬅️ jalanin hub websocket
+	go utils.RunPriceScheduler(time.Minute) // terapkan harga produk terjadwal
+	go utils.RunQuoteCleanup(time.Hour)     // hapus quote expired yang tidak jadi order
+	go utils.RunMemorySweep(time.Minute)    // bersihkan fallback memory yang expired
 	r.Static("/uploads", "./uploads")
 	// r.Use(middlewares.CORSMiddleware()) //development
 	routes.RegisterRoutes(r)
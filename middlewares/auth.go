@@ -11,6 +11,12 @@ import (
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// client mesin pakai X-API-Key
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			authenticateAPIKey(c, key)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
@@ -23,6 +29,8 @@ func AuthMiddleware() gin.HandlerFunc {
 		if err != nil {
 			if err == utils.ErrTokenExpired {
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
+			} else if err == utils.ErrTokenRevoked {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token revoked"})
 			} else {
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			}
@@ -37,8 +45,63 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// simpan user_id ke context
+		utils.TouchSession(claims.SessionID, c.ClientIP())
+
+		// simpan user_id & role ke context
+		role := utils.NormalizeRole(claims.Role)
 		c.Set("user_id", claims.UserID)
+		c.Set("role", role)
+		c.Set("claims", claims)
+		c.Set("principal", &utils.Principal{Type: utils.PrincipalUser, ID: claims.UserID, Role: role})
+
+		// token impersonation: semua request dicatat ke audit log
+		if claims.Act != nil {
+			c.Set("impersonator_id", claims.Act.Sub)
+			c.Next()
+
+			entry := utils.AuditFromContext(c, "impersonation.request")
+			entry.StatusCode = c.Writer.Status()
+			utils.WriteAuditLog(entry, nil)
+			return
+		}
+
 		c.Next()
 	}
 }
+
+// OptionalAuthMiddleware untuk route publik yang butuh tahu siapa yang login kalau ada.
+// Tanpa header auth request lanjut sebagai guest (user_id kosong), tapi token yang dikirim tetap harus valid.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" && c.GetHeader("X-API-Key") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// authenticateAPIKey memvalidasi X-API-Key. API key tidak mewakili user,
+// jadi user_id & role kosong dan akses ditentukan dari scope.
+func authenticateAPIKey(c *gin.Context, key string) {
+	apiKey, err := utils.ValidateAPIKey(key, c.ClientIP())
+	if err != nil {
+		switch err {
+		case utils.ErrAPIKeyExpired:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API key expired"})
+		case utils.ErrAPIKeyRevoked:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API key revoked"})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		}
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", "")
+	c.Set("role", "")
+	c.Set("api_key_id", apiKey.ID.String())
+	c.Set("principal", &utils.Principal{Type: utils.PrincipalAPIKey, ID: apiKey.ID.String(), Scopes: apiKey.Scopes})
+	c.Next()
+}
@@ -0,0 +1,95 @@
+package middlewares
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole hanya mengizinkan request dari salah satu role yang disebut.
+// Harus dipasang setelah AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, r := range roles {
+			if utils.NormalizeRole(r) == role {
+				c.Next()
+				return
+			}
+		}
+
+		logDenied(c, "role", roles)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		c.Abort()
+	}
+}
+
+// RequirePermission mewajibkan role user (atau scope API key) punya semua permission yang disebut.
+// Harus dipasang setelah AuthMiddleware.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := utils.CurrentPrincipal(c)
+		for _, p := range permissions {
+			if !principal.Can(p) {
+				logDenied(c, "permission", permissions)
+				c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func logDenied(c *gin.Context, kind string, required []string) {
+	log.Printf("🚫 Access denied: user=%s api_key=%s role=%s %s=%v %s %s ip=%s",
+		c.GetString("user_id"),
+		c.GetString("api_key_id"),
+		c.GetString("role"),
+		kind,
+		required,
+		c.Request.Method,
+		c.FullPath(),
+		c.ClientIP(),
+	)
+}
+
+// RequireSelfOrPermission mengizinkan request kalau ID user di path/query (param)
+// sama dengan user yang login, atau role-nya punya permission yang disebut.
+func RequireSelfOrPermission(param, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		target := c.Param(param)
+		if target == "" {
+			target = c.Query(param)
+		}
+
+		if target != "" && target == c.GetString("user_id") {
+			c.Next()
+			return
+		}
+		if utils.CurrentPrincipal(c).Can(permission) {
+			c.Next()
+			return
+		}
+
+		logDenied(c, "owner-or-permission", []string{permission})
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		c.Abort()
+	}
+}
+
+// DenyImpersonation menolak request dari token impersonation
+// (ganti password, 2FA, sesi login, data akun).
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("impersonator_id") != "" {
+			logDenied(c, "impersonation", nil)
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed while impersonating"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Address adalah buku alamat customer (rumah, toko, proyek, ...).
+// Order menyimpan salinan isi alamat saat order dibuat, jadi alamat boleh diubah / dihapus.
+type Address struct {
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID        uuid.UUID      `json:"user_id" gorm:"type:uuid;index"`
+	Label         string         `json:"label"` // contoh: "Rumah", "Toko", "Proyek Cibubur"
+	RecipientName string         `json:"recipient_name"`
+	Phone         string         `json:"phone"`
+	Address       string         `json:"address" gorm:"type:text"`
+	Regency       string         `json:"regency"`
+	District      string         `json:"district"`
+	Notes         string         `json:"notes" gorm:"type:text"` // patokan, jam bisa dikirim, dst
+	Lat           float64        `json:"lat"`
+	Lang          float64        `json:"lang"`
+	IsDefault     bool           `json:"is_default"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (a *Address) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.New()
+	return
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// APIKey dipakai client mesin (ERP, build server) tanpa JWT user.
+// Key asli hanya ditampilkan sekali saat dibuat, yang disimpan hanya hash-nya.
+type APIKey struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
+	Name       string         `json:"name"`
+	Prefix     string         `json:"prefix" gorm:"uniqueIndex"` // bagian depan key untuk lookup, aman ditampilkan
+	KeyHash    string         `json:"-"`
+	Scopes     pq.StringArray `json:"scopes" swaggertype:"array,string" gorm:"type:text[]"` // contoh: catalog:read, orders:write
+	ExpiresAt  *time.Time     `json:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	LastUsedIP string         `json:"last_used_ip"`
+	RevokedAt  *time.Time     `json:"revoked_at"`
+	CreatedBy  uuid.UUID      `json:"created_by" gorm:"type:uuid"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) (err error) {
+	k.ID = uuid.New()
+	return
+}
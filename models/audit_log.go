@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLog mencatat aksi sensitif (impersonation, merge, hapus data, dst).
+// Details berisi JSON bebas sesuai jenis aksi.
+type AuditLog struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	Action     string    `json:"action" gorm:"index"` // contoh: impersonation.start, impersonation.request
+	ActorID    uuid.UUID `json:"actor_id" gorm:"type:uuid;index"`
+	ActorRole  string    `json:"actor_role"`
+	SubjectID  uuid.UUID `json:"subject_id" gorm:"type:uuid;index"` // user yang terdampak
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	StatusCode int       `json:"status_code"`
+	IP         string    `json:"ip"`
+	Details    string    `json:"details" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.New()
+	return
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Company pembeli B2B (kontraktor, toko bangunan) yang order lewat beberapa karyawan
+type Company struct {
+	ID              uuid.UUID       `json:"id" gorm:"type:uuid;primaryKey"`
+	Name            string          `json:"name"`
+	NPWP            string          `json:"npwp" gorm:"index:idx_companies_npwp,unique,where:npwp <> ''"` // 15/16 digit, tanpa titik
+	Email           string          `json:"email"`
+	Phone           string          `json:"phone"`
+	BillingAddress  string          `json:"billing_address" gorm:"type:text"`
+	BillingRegency  string          `json:"billing_regency"`
+	BillingDistrict string          `json:"billing_district"`
+	Members         []CompanyMember `json:"members,omitempty" gorm:"foreignKey:CompanyID"` // optional preload
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt  `gorm:"index" json:"-"`
+}
+
+func (c *Company) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return
+}
+
+// CompanyMember user yang boleh order atas nama company.
+// Role: owner (kelola company & anggota) atau purchaser (hanya order).
+// Status invited = undangan yang belum diterima user, belum dihitung sebagai anggota.
+type CompanyMember struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	CompanyID uuid.UUID `json:"company_id" gorm:"type:uuid;uniqueIndex:idx_company_members_company_user"`
+	Company   *Company  `json:"company,omitempty" gorm:"foreignKey:CompanyID"` // optional preload
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;uniqueIndex:idx_company_members_company_user;index"`
+	User      *User     `json:"user,omitempty" gorm:"foreignKey:UserID"` // optional preload
+	Role      string    `json:"role"`
+	Status    string    `json:"status" gorm:"default:'active'"` // active, invited
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (m *CompanyMember) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New()
+	return
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CustomerTag label customer yang dibuat admin (contoh: kontraktor, repeat buyer)
+type CustomerTag struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	Name        string    `json:"name" gorm:"uniqueIndex"`
+	Color       string    `json:"color"` // hex untuk badge di dashboard, contoh: #1e88e5
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (t *CustomerTag) BeforeCreate(tx *gorm.DB) (err error) {
+	t.ID = uuid.New()
+	return
+}
+
+// CustomerNote catatan internal staff tentang customer, tidak pernah terlihat oleh customer
+type CustomerNote struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID      `json:"user_id" gorm:"type:uuid;index"`    // customer
+	AuthorID  uuid.UUID      `json:"author_id" gorm:"type:uuid;index"`  // staff yang menulis
+	Author    User           `json:"author" gorm:"foreignKey:AuthorID"` // optional preload
+	Body      string         `json:"body" gorm:"type:text"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (n *CustomerNote) BeforeCreate(tx *gorm.DB) (err error) {
+	n.ID = uuid.New()
+	return
+}
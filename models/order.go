@@ -11,20 +11,41 @@ type Order struct {
 	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
 	OrderCode string    `json:"order_id" gorm:"uniqueIndex"` // contoh: ORD123
 	// UserID and ProductID are foreign keys to User and Product models
-	UserID      uuid.UUID           `json:"user_id" gorm:"type:uuid"`            // FK to User
-	User        User                `json:"user" gorm:"foreignKey:UserID"`       // optional preload
-	ProductID   uuid.UUID           `json:"product_id" gorm:"type:uuid"`         // FK to Product
-	Product     Product             `json:"product" gorm:"foreignKey:ProductID"` // optional preload
-	CompanyName string              `json:"company_name" gorm:"type:text"`       // name of the product
-	Priority    string              `json:"priority" gorm:"default:'normal'"`    // e.g., "low", "normal", "high"
-	Details     string              `json:"details" gorm:"type:text"`            // additional details about the order
-	Address     string              `json:"address" gorm:"type:text"`            // delivery address
-	Quantity    int                 `json:"quantity"`
-	Updates     []OrderStatusUpdate `json:"updates" gorm:"foreignKey:OrderID"`
-	Status      string              `json:"status"` // e.g., "pending", "completed",
-	CreatedAt   time.Time           `json:"created_at"`
-	UpdatedAt   time.Time           `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt      `gorm:"index" json:"-"`
+	UserID      uuid.UUID `json:"user_id" gorm:"type:uuid"`            // FK to User
+	User        User      `json:"user" gorm:"foreignKey:UserID"`       // optional preload
+	ProductID   uuid.UUID `json:"product_id" gorm:"type:uuid"`         // FK to Product
+	Product     Product   `json:"product" gorm:"foreignKey:ProductID"` // optional preload
+	CompanyName string    `json:"company_name" gorm:"type:text"`       // name of the product
+	Priority    string    `json:"priority" gorm:"default:'normal'"`    // e.g., "low", "normal", "high"
+	Details     string    `json:"details" gorm:"type:text"`            // additional details about the order
+	Address     string    `json:"address" gorm:"type:text"`            // delivery address
+	// alamat dari buku alamat + salinan isinya saat order dibuat
+	AddressID       *uuid.UUID          `json:"address_id" gorm:"type:uuid;index"`
+	AddressLabel    string              `json:"address_label"`
+	AddressName     string              `json:"address_recipient_name"`
+	AddressPhone    string              `json:"address_phone"`
+	AddressRegency  string              `json:"address_regency"`
+	AddressDistrict string              `json:"address_district"`
+	AddressNotes    string              `json:"address_notes" gorm:"type:text"`
+	AddressLat      float64             `json:"address_lat"`
+	AddressLang     float64             `json:"address_lang"`
+	CompanyID       *uuid.UUID          `json:"company_id" gorm:"type:uuid;index"`             // company B2B pemesan (nil = order pribadi)
+	Company         *Company            `json:"company,omitempty" gorm:"foreignKey:CompanyID"` // optional preload
+	VariantID       *uuid.UUID          `json:"variant_id" gorm:"type:uuid;index"`             // varian yang dipilih (nil = produk tanpa varian)
+	Variant         *ProductVariant     `json:"variant,omitempty" gorm:"foreignKey:VariantID"` // optional preload
+	VariantSKU      string              `json:"variant_sku"`                                   // salinan SKU & nama varian saat order dibuat
+	VariantName     string              `json:"variant_name"`
+	QuoteID         *uuid.UUID          `json:"quote_id" gorm:"type:uuid;index"`           // quote ukuran custom (POST /products/:id/quote)
+	Quote           *OrderQuote         `json:"quote,omitempty" gorm:"foreignKey:QuoteID"` // optional preload
+	Quantity        int                 `json:"quantity"`
+	UnitPrice       int64               `json:"unit_price"` // salinan harga produk saat order dibuat
+	PriceUnit       string              `json:"price_unit"`
+	Subtotal        int64               `json:"subtotal"` // UnitPrice x Quantity, 0 = belum ada harga
+	Updates         []OrderStatusUpdate `json:"updates" gorm:"foreignKey:OrderID"`
+	Status          string              `json:"status"` // e.g., "pending", "completed",
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt      `gorm:"index" json:"-"`
 }
 
 // Auto-generate UUID before insert
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OTPCode struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	Phone      string     `json:"phone" gorm:"index"`
+	Purpose    string     `json:"purpose"` // contoh: "login"
+	CodeHash   string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Attempts   int        `json:"attempts"`
+	ConsumedAt *time.Time `json:"consumed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (o *OTPCode) BeforeCreate(tx *gorm.DB) (err error) {
+	o.ID = uuid.New()
+	return
+}
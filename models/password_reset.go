@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken adalah token sekali pakai untuk reset password / aktivasi akun.
+// Yang disimpan hanya hash-nya.
+type PasswordResetToken struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserAccountID uuid.UUID  `json:"user_account_id" gorm:"type:uuid;index"`
+	TokenHash     string     `json:"-" gorm:"uniqueIndex"`
+	Purpose       string     `json:"purpose"` // "reset" atau "activation"
+	ExpiresAt     time.Time  `json:"expires_at"`
+	UsedAt        *time.Time `json:"used_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (p *PasswordResetToken) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.New()
+	return
+}
+
+// PasswordChangeLog mencatat setiap perubahan password
+type PasswordChangeLog struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	UserAccountID uuid.UUID `json:"user_account_id" gorm:"type:uuid;index"`
+	Reason        string    `json:"reason"` // "change", "reset", "activation"
+	IP            string    `json:"ip"`
+	UserAgent     string    `json:"user_agent"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (p *PasswordChangeLog) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.New()
+	return
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PricingFormula rumus harga produk made-to-measure (jendela, pintu sliding, shower screen)
+// yang dihitung dari lebar x tinggi. Satu produk paling banyak satu rumus.
+type PricingFormula struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	ProductID       uuid.UUID `json:"product_id" gorm:"type:uuid;uniqueIndex"`
+	AreaRate        int64     `json:"area_rate"`         // Rupiah per m² (lebar x tinggi), + price_modifier varian
+	PerimeterRate   int64     `json:"perimeter_rate"`    // Rupiah per meter keliling (kusen / profil)
+	PieceFee        int64     `json:"piece_fee"`         // Rupiah per pcs (engsel, handle, ongkos rakit)
+	MinAreaM2       float64   `json:"min_area_m2"`       // luas minimum yang ditagih per pcs
+	MinCharge       int64     `json:"min_charge"`        // total minimum per baris order
+	MinWidthMM      int       `json:"min_width_mm"`      // 0 = tanpa batas
+	MaxWidthMM      int       `json:"max_width_mm"`      // 0 = tanpa batas
+	MinHeightMM     int       `json:"min_height_mm"`     // 0 = tanpa batas
+	MaxHeightMM     int       `json:"max_height_mm"`     // 0 = tanpa batas
+	DimensionStepMM int       `json:"dimension_step_mm"` // ukuran dibulatkan ke atas ke kelipatan ini, 0 = tidak dibulatkan
+	RoundTo         int64     `json:"round_to"`          // total dibulatkan ke atas ke kelipatan ini, 0 = tidak dibulatkan
+	QuoteValidDays  int       `json:"quote_valid_days"`  // masa berlaku quote, 0 = default
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (f *PricingFormula) BeforeCreate(tx *gorm.DB) (err error) {
+	f.ID = uuid.New()
+	return
+}
+
+// QuoteLine satu baris rincian biaya quote
+type QuoteLine struct {
+	Code        string  `json:"code"` // area, perimeter, piece, minimum_charge, rounding
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit"`
+	Rate        int64   `json:"rate"`
+	Amount      int64   `json:"amount"`
+}
+
+// OrderQuote hasil hitung harga dari PricingFormula. Disimpan supaya order bisa
+// merujuk quote_id tanpa menghitung ulang; OrderID terisi setelah dipakai order.
+type OrderQuote struct {
+	ID               uuid.UUID   `json:"id" gorm:"type:uuid;primaryKey"`
+	ProductID        uuid.UUID   `json:"product_id" gorm:"type:uuid;index"`
+	VariantID        *uuid.UUID  `json:"variant_id" gorm:"type:uuid"`
+	WidthMM          int         `json:"width_mm"`  // setelah dibulatkan
+	HeightMM         int         `json:"height_mm"` // setelah dibulatkan
+	Quantity         int         `json:"quantity"`
+	AreaM2           float64     `json:"area_m2"`            // per pcs
+	ChargeableAreaM2 float64     `json:"chargeable_area_m2"` // per pcs, min. MinAreaM2
+	PerimeterM       float64     `json:"perimeter_m"`        // per pcs
+	Lines            []QuoteLine `json:"lines" gorm:"serializer:json;type:text"`
+	Subtotal         int64       `json:"subtotal"` // jumlah baris area + perimeter + piece
+	Total            int64       `json:"total"`    // setelah minimum charge & pembulatan
+	UnitPrice        int64       `json:"unit_price"`
+	ExpiresAt        time.Time   `json:"expires_at"`
+	OrderID          *uuid.UUID  `json:"order_id" gorm:"type:uuid;index"`
+	CreatedAt        time.Time   `json:"created_at"`
+}
+
+func (q *OrderQuote) BeforeCreate(tx *gorm.DB) (err error) {
+	q.ID = uuid.New()
+	return
+}
@@ -22,15 +22,21 @@ import (
 // @type array
 // @items type string
 type Product struct {
-	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
-	Name       string         `json:"name"`
-	Detail     string         `json:"detail"`
-	Images     pq.StringArray `json:"images" swaggertype:"array,string" gorm:"type:text[]"`
-	CategoryID uuid.UUID      `json:"category_id" gorm:"type:uuid"`
-	Category   Category       `json:"category" gorm:"foreignKey:CategoryID"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
+	Name        string         `json:"name"`
+	Detail      string         `json:"detail"`
+	Images      pq.StringArray `json:"images" swaggertype:"array,string" gorm:"type:text[]"`
+	Price       int64          `json:"price"`                            // Rupiah per PriceUnit (0 = by quote), riwayat di ProductPrice
+	PriceUnit   string         `json:"price_unit" gorm:"default:'unit'"` // unit, metre, m2
+	MinOrderQty int            `json:"min_order_qty" gorm:"default:1"`   // dalam PriceUnit
+	CategoryID  uuid.UUID      `json:"category_id" gorm:"type:uuid"`
+	Category    Category       `json:"category" gorm:"foreignKey:CategoryID"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	// pilihan (warna, ketebalan, ...) dan varian, hanya terisi kalau di-preload
+	OptionGroups []ProductOptionGroup `json:"option_groups,omitempty" gorm:"foreignKey:ProductID"`
+	Variants     []ProductVariant     `json:"variants,omitempty" gorm:"foreignKey:ProductID"`
 }
 
 // Auto-generate UUID before insert
@@ -38,3 +44,27 @@ func (p *Product) BeforeCreate(tx *gorm.DB) (err error) {
 	p.ID = uuid.New()
 	return
 }
+
+// ProductPrice adalah riwayat + jadwal harga produk. Baris dengan AppliedAt nil
+// masih menunggu EffectiveAt; setelah diterapkan, Previous* berisi harga sebelumnya.
+type ProductPrice struct {
+	ID                  uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	ProductID           uuid.UUID  `json:"product_id" gorm:"type:uuid;index"`
+	Amount              int64      `json:"amount"`
+	Unit                string     `json:"unit"`
+	MinOrderQty         int        `json:"min_order_qty"`
+	PreviousAmount      int64      `json:"previous_amount"`
+	PreviousUnit        string     `json:"previous_unit"`
+	PreviousMinOrderQty int        `json:"previous_min_order_qty"`
+	Note                string     `json:"note" gorm:"type:text"`
+	EffectiveAt         time.Time  `json:"effective_at" gorm:"index"`
+	AppliedAt           *time.Time `json:"applied_at" gorm:"index"`
+	ChangedBy           uuid.UUID  `json:"changed_by" gorm:"type:uuid;index"` // user / API key yang membuat perubahan
+	ChangedByType       string     `json:"changed_by_type"`                   // user, api_key
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+func (p *ProductPrice) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.New()
+	return
+}
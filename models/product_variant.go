@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// ProductOptionGroup jenis pilihan sebuah produk, contoh: "Warna", "Ketebalan"
+type ProductOptionGroup struct {
+	ID        uuid.UUID            `json:"id" gorm:"type:uuid;primaryKey"`
+	ProductID uuid.UUID            `json:"product_id" gorm:"type:uuid;index"`
+	Name      string               `json:"name"`
+	Position  int                  `json:"position"`
+	Values    []ProductOptionValue `json:"values" gorm:"foreignKey:GroupID"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+func (g *ProductOptionGroup) BeforeCreate(tx *gorm.DB) (err error) {
+	g.ID = uuid.New()
+	return
+}
+
+// ProductOptionValue isi pilihan dalam satu group, contoh: "silver", "hitam", "8mm tempered"
+type ProductOptionValue struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	GroupID   uuid.UUID `json:"group_id" gorm:"type:uuid;index"`
+	Value     string    `json:"value"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (v *ProductOptionValue) BeforeCreate(tx *gorm.DB) (err error) {
+	v.ID = uuid.New()
+	return
+}
+
+// ProductVariant kombinasi pilihan (1 value per group) yang benar-benar dijual,
+// dengan SKU, selisih harga dan gambar sendiri.
+type ProductVariant struct {
+	ID            uuid.UUID            `json:"id" gorm:"type:uuid;primaryKey"`
+	ProductID     uuid.UUID            `json:"product_id" gorm:"type:uuid;index"`
+	SKU           string               `json:"sku" gorm:"index:idx_product_variants_sku,unique,where:deleted_at IS NULL"`
+	Name          string               `json:"name"`           // gabungan value, contoh: "hitam / 8mm"
+	PriceModifier int64                `json:"price_modifier"` // Rupiah, ditambahkan ke Product.Price (boleh negatif)
+	Images        pq.StringArray       `json:"images" swaggertype:"array,string" gorm:"type:text[]"`
+	IsActive      bool                 `json:"is_active"`
+	Options       []ProductOptionValue `json:"options" gorm:"many2many:product_variant_options"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt       `gorm:"index" json:"-"`
+}
+
+func (v *ProductVariant) BeforeCreate(tx *gorm.DB) (err error) {
+	v.ID = uuid.New()
+	return
+}
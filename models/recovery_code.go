@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecoveryCode adalah kode cadangan 2FA (sekali pakai). Yang disimpan hanya hash-nya.
+type RecoveryCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	AccountID uuid.UUID  `json:"account_id" gorm:"type:uuid;index"`
+	CodeHash  string     `json:"-" gorm:"index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (r *RecoveryCode) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken adalah fallback Postgres untuk refresh token (utama di Redis).
+// Yang disimpan hanya hash token, bukan token aslinya.
+type RefreshToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	FamilyID  uuid.UUID  `json:"family_id" gorm:"type:uuid;index"` // semua token hasil rotasi dari 1 login
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (r *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
+
+// RevokedToken adalah daftar access token (jti) yang sudah dicabut sebelum expired
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"primaryKey"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
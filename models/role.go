@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Permission struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	Name        string    `json:"name" gorm:"uniqueIndex"` // contoh: "orders:write"
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (p *Permission) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.New()
+	return
+}
+
+type Role struct {
+	ID          uuid.UUID    `json:"id" gorm:"type:uuid;primaryKey"`
+	Name        string       `json:"name" gorm:"uniqueIndex"` // contoh: "admin", "sales"
+	Description string       `json:"description"`
+	IsStaff     bool         `json:"is_staff"` // false hanya untuk customer
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func (r *Role) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session adalah 1 login di 1 perangkat. ID-nya sama dengan sid di JWT
+// (family refresh token), jadi mencabut session = mencabut family-nya.
+type Session struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	Device     string     `json:"device"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
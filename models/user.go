@@ -19,6 +19,10 @@ type User struct {
 	IsActive bool      `json:"is_active" gorm:"default:false"`
 	Lat      float64   `json:"lat"`
 	Lang     float64   `json:"lang"`
+	// diisi saat data pribadi dihapus (UU PDP); row tetap ada untuk statistik order
+	ErasedAt *time.Time `json:"erased_at"`
+	// tag CRM dari admin, hanya terisi kalau di-preload
+	Tags []CustomerTag `json:"tags,omitempty" gorm:"many2many:user_tags"`
 
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -8,15 +8,20 @@ import (
 )
 
 type UserAccount struct {
-	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
-	Phone        string         `json:"phone"`
-	PasswordHash string         `json:"password_hash"`
-	Role         string         `json:"role"`
-	UserID       uuid.UUID      `json:"user_id" gorm:"type:uuid"`      // FK to Category
-	User         User           `json:"user" gorm:"foreignKey:UserID"` // optional preload
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
+	Phone         string         `json:"phone"`
+	PasswordHash  string         `json:"-"`
+	RoleID        uuid.UUID      `json:"role_id" gorm:"type:uuid"`      // FK to Role
+	Role          Role           `json:"role" gorm:"foreignKey:RoleID"` // optional preload
+	UserID        uuid.UUID      `json:"user_id" gorm:"type:uuid"`      // FK to Category
+	User          User           `json:"user" gorm:"foreignKey:UserID"` // optional preload
+	DeactivatedAt *time.Time     `json:"deactivated_at"`                // akun staff yang dinonaktifkan admin
+	TOTPSecret    string         `json:"-"`                             // secret authenticator (2FA)
+	TOTPEnabledAt *time.Time     `json:"totp_enabled_at"`               // nil = 2FA belum aktif
+	TOTPLastStep  int64          `json:"-"`                             // periode TOTP terakhir yang dipakai (anti replay)
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // Auto-generate UUID before insert
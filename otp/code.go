@@ -0,0 +1,67 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"math/big"
+	"os"
+	"time"
+)
+
+const (
+	CodeLength  = 6
+	CodeTTL     = 5 * time.Minute
+	MaxAttempts = 5
+	// batas permintaan kode per nomor
+	ResendInterval = 60 * time.Second
+	MaxPerHour     = 5
+)
+
+// devSecret hanya dipakai kalau OTP_SECRET kosong dan server jalan di dev mode
+const devSecret = "otp-dev-secret"
+
+var secret []byte
+
+// LoadSecret membaca OTP_SECRET. Dipanggil sekali saat startup; tanpa OTP_SECRET server
+// hanya boleh jalan di dev mode.
+func LoadSecret(devMode bool) error {
+	value := os.Getenv("OTP_SECRET")
+	if value == "" {
+		if !devMode {
+			return errors.New("OTP_SECRET is not set")
+		}
+		log.Println("⚠️ OTP_SECRET not set, using built-in dev secret")
+		value = devSecret
+	}
+	secret = []byte(value)
+	return nil
+}
+
+// GenerateCode membuat kode numerik acak sepanjang CodeLength
+func GenerateCode() (string, error) {
+	code := make([]byte, CodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+	return string(code), nil
+}
+
+// HashCode membuat HMAC kode yang terikat ke nomor HP, jadi hash tidak bisa dipakai untuk nomor lain
+func HashCode(phone, code string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(phone + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CheckCode membandingkan kode dengan hash secara constant-time
+func CheckCode(phone, code, hash string) bool {
+	return hmac.Equal([]byte(HashCode(phone, code)), []byte(hash))
+}
@@ -0,0 +1,71 @@
+package otp
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sender mengirim pesan (kode OTP, link reset, dll) ke nomor HP.
+// Implementasi produksi (WhatsApp / SMS gateway) tinggal memenuhi interface ini.
+type Sender interface {
+	Send(phone, message string) error
+}
+
+// LogSender hanya menulis pesan ke log, untuk development lokal
+type LogSender struct{}
+
+func (LogSender) Send(phone, message string) error {
+	log.Printf("📨 [otp:log] to=%s message=%q", phone, message)
+	return nil
+}
+
+// FileSender menambahkan pesan ke file, berguna untuk QA / testing manual
+type FileSender struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (f *FileSender) Send(phone, message string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), phone, message)
+	return err
+}
+
+var (
+	defaultSender Sender
+	senderOnce    sync.Once
+)
+
+// DefaultSender dipilih dari env OTP_SENDER (log | file). Default: log.
+func DefaultSender() Sender {
+	senderOnce.Do(func() {
+		switch os.Getenv("OTP_SENDER") {
+		case "file":
+			path := os.Getenv("OTP_FILE_PATH")
+			if path == "" {
+				path = "otp_messages.log"
+			}
+			defaultSender = &FileSender{Path: path}
+		default:
+			defaultSender = LogSender{}
+		}
+	})
+	return defaultSender
+}
+
+// SetSender mengganti sender default (misal dengan gateway WhatsApp di main)
+func SetSender(s Sender) {
+	senderOnce.Do(func() {})
+	defaultSender = s
+}
@@ -0,0 +1,202 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ary/go-api/models"
+)
+
+const (
+	DefaultQuoteValidity = 7 * 24 * time.Hour
+	MaxQuantity          = 1000
+	// batas keras ukuran, berlaku juga kalau rumus produk tidak punya batas (max 0)
+	MaxDimensionMM = 10000
+	// batas total quote (Rupiah), hasil di atas ini dianggap rumus / input tidak wajar
+	MaxTotal = 1_000_000_000_000
+)
+
+var (
+	ErrInvalidDimension = errors.New("width and height must be greater than zero")
+	ErrInvalidQuantity  = errors.New("quantity must be between 1 and 1000")
+	ErrInvalidTotal     = errors.New("calculated price is out of range")
+)
+
+// Input ukuran dan jumlah yang diminta customer
+type Input struct {
+	WidthMM      int
+	HeightMM     int
+	Quantity     int
+	RateModifier int64 // price_modifier varian, ditambahkan ke AreaRate
+}
+
+// DimensionError ukuran di luar batas rumus produk
+type DimensionError struct {
+	Field string
+	Min   int
+	Max   int
+}
+
+func (e *DimensionError) Error() string {
+	switch {
+	case e.Min > 0 && e.Max > 0:
+		return fmt.Sprintf("%s must be between %d and %d mm", e.Field, e.Min, e.Max)
+	case e.Min > 0:
+		return fmt.Sprintf("%s must be at least %d mm", e.Field, e.Min)
+	default:
+		return fmt.Sprintf("%s must be at most %d mm", e.Field, e.Max)
+	}
+}
+
+// Calculate menghitung quote dari rumus produk:
+//
+//  1. lebar & tinggi dibulatkan ke atas ke DimensionStepMM
+//  2. luas per pcs minimal MinAreaM2, dikali (AreaRate + RateModifier)
+//  3. keliling per pcs dikali PerimeterRate, ditambah PieceFee per pcs
+//  4. kalau subtotal < MinCharge, selisihnya ditagih sebagai minimum charge
+//  5. total dibulatkan ke atas ke kelipatan RoundTo
+//
+// Hasilnya belum disimpan (ID, ProductID, VariantID diisi pemanggil).
+func Calculate(f models.PricingFormula, in Input) (*models.OrderQuote, error) {
+	if in.WidthMM <= 0 || in.HeightMM <= 0 {
+		return nil, ErrInvalidDimension
+	}
+	if in.Quantity < 1 || in.Quantity > MaxQuantity {
+		return nil, ErrInvalidQuantity
+	}
+	if err := checkRange("width", in.WidthMM, f.MinWidthMM, maxDimension(f.MaxWidthMM)); err != nil {
+		return nil, err
+	}
+	if err := checkRange("height", in.HeightMM, f.MinHeightMM, maxDimension(f.MaxHeightMM)); err != nil {
+		return nil, err
+	}
+
+	width := roundUpInt(in.WidthMM, f.DimensionStepMM)
+	height := roundUpInt(in.HeightMM, f.DimensionStepMM)
+	area := float64(width) * float64(height) / 1e6
+	perimeter := 2 * float64(width+height) / 1000
+	chargeable := math.Max(area, f.MinAreaM2)
+	qty := float64(in.Quantity)
+
+	q := &models.OrderQuote{
+		WidthMM:          width,
+		HeightMM:         height,
+		Quantity:         in.Quantity,
+		AreaM2:           round4(area),
+		ChargeableAreaM2: round4(chargeable),
+		PerimeterM:       round4(perimeter),
+		Lines:            []models.QuoteLine{},
+	}
+
+	// varian bisa lebih murah, tapi harga per m² tidak boleh negatif
+	if rate := max(f.AreaRate+in.RateModifier, 0); rate != 0 {
+		if err := addLine(q, "area", "Luas kaca / panel", round4(chargeable*qty), "m2", rate); err != nil {
+			return nil, err
+		}
+	}
+	if f.PerimeterRate != 0 {
+		if err := addLine(q, "perimeter", "Profil / kusen keliling", round4(perimeter*qty), "m", f.PerimeterRate); err != nil {
+			return nil, err
+		}
+	}
+	if f.PieceFee != 0 {
+		if err := addLine(q, "piece", "Aksesoris & perakitan", qty, "pcs", f.PieceFee); err != nil {
+			return nil, err
+		}
+	}
+	q.Subtotal = q.Total
+
+	if q.Total < f.MinCharge {
+		q.Lines = append(q.Lines, models.QuoteLine{
+			Code:        "minimum_charge",
+			Description: "Penyesuaian minimum order",
+			Amount:      f.MinCharge - q.Total,
+		})
+		q.Total = f.MinCharge
+	}
+	if rounded := roundUpInt64(q.Total, f.RoundTo); rounded != q.Total {
+		q.Lines = append(q.Lines, models.QuoteLine{
+			Code:        "rounding",
+			Description: fmt.Sprintf("Pembulatan ke Rp %d", f.RoundTo),
+			Amount:      rounded - q.Total,
+		})
+		q.Total = rounded
+	}
+	if q.Total <= 0 || q.Total > MaxTotal {
+		return nil, ErrInvalidTotal
+	}
+	// harga per pcs dibulatkan ke bawah; yang ditagih tetap Total
+	q.UnitPrice = q.Total / int64(in.Quantity)
+
+	validity := DefaultQuoteValidity
+	if f.QuoteValidDays > 0 {
+		validity = time.Duration(f.QuoteValidDays) * 24 * time.Hour
+	}
+	q.ExpiresAt = time.Now().Add(validity)
+	return q, nil
+}
+
+// addLine tambah baris biaya, amount dibulatkan ke Rupiah terdekat.
+// Amount yang tidak wajar (Inf / NaN / di atas MaxTotal) ditolak sebelum dikonversi ke int64.
+func addLine(q *models.OrderQuote, code, description string, quantity float64, unit string, rate int64) error {
+	value := math.Round(quantity * float64(rate))
+	if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 || value > MaxTotal {
+		return ErrInvalidTotal
+	}
+	amount := int64(value)
+	q.Lines = append(q.Lines, models.QuoteLine{
+		Code:        code,
+		Description: description,
+		Quantity:    quantity,
+		Unit:        unit,
+		Rate:        rate,
+		Amount:      amount,
+	})
+	q.Total += amount
+	if q.Total > MaxTotal {
+		return ErrInvalidTotal
+	}
+	return nil
+}
+
+func checkRange(field string, value, minMM, maxMM int) error {
+	if (minMM > 0 && value < minMM) || (maxMM > 0 && value > maxMM) {
+		return &DimensionError{Field: field, Min: minMM, Max: maxMM}
+	}
+	return nil
+}
+
+// maxDimension batas rumus produk, dibatasi MaxDimensionMM (0 = tanpa batas dari rumus)
+func maxDimension(formulaMax int) int {
+	if formulaMax <= 0 || formulaMax > MaxDimensionMM {
+		return MaxDimensionMM
+	}
+	return formulaMax
+}
+
+func roundUpInt(value, step int) int {
+	if step <= 0 {
+		return value
+	}
+	return (value + step - 1) / step * step
+}
+
+func roundUpInt64(value, step int64) int64 {
+	if step <= 0 || value <= 0 {
+		return value
+	}
+	rem := value % step
+	if rem == 0 {
+		return value
+	}
+	if value > math.MaxInt64-(step-rem) {
+		return math.MaxInt64 // overflow, ditolak lewat MaxTotal
+	}
+	return value + step - rem
+}
+
+func round4(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
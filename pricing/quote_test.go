@@ -0,0 +1,191 @@
+package pricing
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/ary/go-api/models"
+)
+
+var testFormula = models.PricingFormula{
+	AreaRate:        450000,
+	PerimeterRate:   85000,
+	PieceFee:        150000,
+	MinAreaM2:       0.5,
+	DimensionStepMM: 10,
+	RoundTo:         1000,
+}
+
+func lineAmount(q *models.OrderQuote, code string) int64 {
+	for _, l := range q.Lines {
+		if l.Code == code {
+			return l.Amount
+		}
+	}
+	return 0
+}
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name      string
+		formula   models.PricingFormula
+		in        Input
+		width     int
+		height    int
+		subtotal  int64
+		total     int64
+		unitPrice int64
+		lines     map[string]int64
+	}{
+		{
+			name:    "exact size, no rounding",
+			formula: testFormula,
+			in:      Input{WidthMM: 1200, HeightMM: 1500, Quantity: 2},
+			width:   1200, height: 1500,
+			subtotal: 2838000, total: 2838000, unitPrice: 1419000,
+			lines: map[string]int64{"area": 1620000, "perimeter": 918000, "piece": 300000, "rounding": 0},
+		},
+		{
+			name:    "dimension step and rounding",
+			formula: testFormula,
+			in:      Input{WidthMM: 1203, HeightMM: 1497, Quantity: 1},
+			width:   1210, height: 1500,
+			subtotal: 1427450, total: 1428000, unitPrice: 1428000,
+			lines: map[string]int64{"area": 816750, "perimeter": 460700, "piece": 150000, "rounding": 550},
+		},
+		{
+			name:    "minimum area",
+			formula: testFormula,
+			in:      Input{WidthMM: 300, HeightMM: 300, Quantity: 1},
+			width:   300, height: 300,
+			subtotal: 477000, total: 477000, unitPrice: 477000,
+			lines: map[string]int64{"area": 225000, "perimeter": 102000, "piece": 150000},
+		},
+		{
+			name:    "minimum charge",
+			formula: models.PricingFormula{AreaRate: 100000, MinCharge: 500000},
+			in:      Input{WidthMM: 1000, HeightMM: 1000, Quantity: 1},
+			width:   1000, height: 1000,
+			subtotal: 100000, total: 500000, unitPrice: 500000,
+			lines: map[string]int64{"area": 100000, "minimum_charge": 400000},
+		},
+		{
+			name:    "variant modifier",
+			formula: models.PricingFormula{AreaRate: 100000},
+			in:      Input{WidthMM: 1000, HeightMM: 1000, Quantity: 1, RateModifier: 50000},
+			width:   1000, height: 1000,
+			subtotal: 150000, total: 150000, unitPrice: 150000,
+			lines: map[string]int64{"area": 150000},
+		},
+		{
+			name:    "unit price truncated, total billed",
+			formula: models.PricingFormula{PieceFee: 100, RoundTo: 1000},
+			in:      Input{WidthMM: 1000, HeightMM: 1000, Quantity: 3},
+			width:   1000, height: 1000,
+			subtotal: 300, total: 1000, unitPrice: 333,
+			lines: map[string]int64{"piece": 300, "rounding": 700},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Calculate(tt.formula, tt.in)
+			if err != nil {
+				t.Fatalf("Calculate: %v", err)
+			}
+			if q.WidthMM != tt.width || q.HeightMM != tt.height {
+				t.Errorf("size = %dx%d, want %dx%d", q.WidthMM, q.HeightMM, tt.width, tt.height)
+			}
+			if q.Subtotal != tt.subtotal || q.Total != tt.total || q.UnitPrice != tt.unitPrice {
+				t.Errorf("subtotal/total/unit = %d/%d/%d, want %d/%d/%d",
+					q.Subtotal, q.Total, q.UnitPrice, tt.subtotal, tt.total, tt.unitPrice)
+			}
+			var sum int64
+			for _, l := range q.Lines {
+				sum += l.Amount
+			}
+			if sum != q.Total {
+				t.Errorf("lines sum to %d, total is %d", sum, q.Total)
+			}
+			for code, want := range tt.lines {
+				if got := lineAmount(q, code); got != want {
+					t.Errorf("line %s = %d, want %d", code, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		formula models.PricingFormula
+		in      Input
+		want    error
+	}{
+		{"zero width", testFormula, Input{WidthMM: 0, HeightMM: 1000, Quantity: 1}, ErrInvalidDimension},
+		{"negative height", testFormula, Input{WidthMM: 1000, HeightMM: -1, Quantity: 1}, ErrInvalidDimension},
+		{"zero quantity", testFormula, Input{WidthMM: 1000, HeightMM: 1000, Quantity: 0}, ErrInvalidQuantity},
+		{"quantity above max", testFormula, Input{WidthMM: 1000, HeightMM: 1000, Quantity: MaxQuantity + 1}, ErrInvalidQuantity},
+		{"zero total", models.PricingFormula{AreaRate: 100000}, Input{WidthMM: 1000, HeightMM: 1000, Quantity: 1, RateModifier: -150000}, ErrInvalidTotal},
+		{"area overflow", models.PricingFormula{AreaRate: math.MaxInt64 / 2}, Input{WidthMM: 1000, HeightMM: 1000, Quantity: 1}, ErrInvalidTotal},
+		{"minimum area overflow", models.PricingFormula{AreaRate: 1, MinAreaM2: 1e300}, Input{WidthMM: 1000, HeightMM: 1000, Quantity: 1}, ErrInvalidTotal},
+		{"minimum charge above max total", models.PricingFormula{PieceFee: 1, MinCharge: MaxTotal + 1}, Input{WidthMM: 1000, HeightMM: 1000, Quantity: 1}, ErrInvalidTotal},
+		{"rounding overflow", models.PricingFormula{PieceFee: 1, RoundTo: math.MaxInt64}, Input{WidthMM: 1000, HeightMM: 1000, Quantity: 1}, ErrInvalidTotal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Calculate(tt.formula, tt.in); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateDimensionLimits(t *testing.T) {
+	bounded := testFormula
+	bounded.MinWidthMM, bounded.MaxWidthMM = 300, 3000
+
+	tests := []struct {
+		name    string
+		formula models.PricingFormula
+		in      Input
+		field   string
+		max     int
+	}{
+		{"formula max", bounded, Input{WidthMM: 3001, HeightMM: 1000, Quantity: 1}, "width", 3000},
+		{"formula min", bounded, Input{WidthMM: 299, HeightMM: 1000, Quantity: 1}, "width", 3000},
+		{"global max without formula limit", testFormula, Input{WidthMM: 1000, HeightMM: MaxDimensionMM + 1, Quantity: 1}, "height", MaxDimensionMM},
+		{"formula max above global max", models.PricingFormula{PieceFee: 1, MaxWidthMM: 50000}, Input{WidthMM: 20000, HeightMM: 1000, Quantity: 1}, "width", MaxDimensionMM},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Calculate(tt.formula, tt.in)
+			var dimErr *DimensionError
+			if !errors.As(err, &dimErr) {
+				t.Fatalf("err = %v, want *DimensionError", err)
+			}
+			if dimErr.Field != tt.field || dimErr.Max != tt.max {
+				t.Errorf("DimensionError = %+v, want field %s max %d", dimErr, tt.field, tt.max)
+			}
+		})
+	}
+}
+
+func TestRoundUpInt64(t *testing.T) {
+	tests := []struct {
+		value, step, want int64
+	}{
+		{1427450, 1000, 1428000},
+		{1428000, 1000, 1428000},
+		{1, 1000, 1000},
+		{1500, 0, 1500},
+		{0, 1000, 0},
+		{math.MaxInt64 - 5, 10, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		if got := roundUpInt64(tt.value, tt.step); got != tt.want {
+			t.Errorf("roundUpInt64(%d, %d) = %d, want %d", tt.value, tt.step, got, tt.want)
+		}
+	}
+}
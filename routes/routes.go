@@ -5,6 +5,7 @@ import (
 	_ "github.com/ary/go-api/docs"
 	"github.com/ary/go-api/middlewares"
 	"github.com/ary/go-api/sse"
+	"github.com/ary/go-api/utils"
 
 	// "github.com/ary/go-api/ws"
 	"github.com/gin-gonic/gin"
@@ -17,6 +18,14 @@ func RegisterRoutes(r *gin.Engine) {
 	{
 		// Auth routes
 		api.POST("/login", controllers.Login)
+		api.POST("/login/2fa", controllers.LoginTwoFactor)
+		api.POST("/login/2fa/setup", controllers.SetupTwoFactorLogin)
+		api.POST("/login/2fa/enable", controllers.EnableTwoFactorLogin)
+		api.POST("/token/refresh", controllers.RefreshToken)
+		api.POST("/otp/request", controllers.RequestOTP)
+		api.POST("/otp/verify", controllers.VerifyOTP)
+		api.POST("/password/forgot", controllers.ForgotPassword)
+		api.POST("/password/reset", controllers.ResetPassword)
 
 		// Public User routes (misalnya register user baru)
 		api.POST("/users", controllers.CreateUser)
@@ -26,61 +35,214 @@ func RegisterRoutes(r *gin.Engine) {
 		api.GET("/products", controllers.GetProducts)
 		api.GET("/products/:id", controllers.GetProductByID)
 		api.GET("/products/best-selling", controllers.GetBestSellingProducts)
+		api.GET("/products/suggest", controllers.GetProductSuggestions) // autocomplete pencarian
+		api.POST("/products/:id/quote", controllers.QuoteProduct)       // hitung harga ukuran custom
 		api.GET("/categories", controllers.GetCategories)
 		api.GET("/categories/:category_id/products", controllers.GetProductByCategoryID)
 
 		// Public Orders (opsional kalau boleh pesan tanpa login)
-		api.POST("/orders", controllers.CreateOrderAndNotify)
+		// token opsional, wajib kalau order atas nama company
+		api.POST("/orders", middlewares.OptionalAuthMiddleware(), controllers.CreateOrderAndNotify)
 
 		api.GET("/info", controllers.GetInfo)
 
 		// Swagger
 		api.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-		// Protected routes (harus pakai token JWT)
+		// Protected routes (harus pakai token JWT atau X-API-Key)
 		protected := api.Group("/")
 		protected.Use(middlewares.AuthMiddleware())
 		{
-			// User
-			protected.GET("/users", controllers.GetUsers)
-			protected.GET("/users/:id", controllers.GetUserByID)
-			protected.GET("/users/:id/is-active", controllers.CheckUserIsActive)
-			protected.PATCH("/users/:id", controllers.UpdateUser)
-			protected.DELETE("/users/:id", controllers.DeleteUser)
+			protected.POST("/logout", controllers.Logout)
+
+			// Ticket untuk koneksi SSE / WebSocket
+			protected.POST("/events/ticket", controllers.CreateEventTicket)
+
+			// Data milik user yang sedang login
+			protected.GET("/me", controllers.GetMe)
+			protected.GET("/me/profile", controllers.GetMyProfile)
+			protected.PATCH("/me/profile", middlewares.DenyImpersonation(), controllers.UpdateMyProfile)
+			protected.GET("/me/orders", controllers.GetMyOrders)
+			protected.GET("/me/notifications", controllers.GetMyNotifications)
+			protected.GET("/me/export", middlewares.DenyImpersonation(), controllers.ExportMyData)
+			protected.GET("/me/companies", controllers.GetMyCompanies)
+			protected.POST("/me/companies/:id/accept", controllers.AcceptCompanyInvite)
+			protected.POST("/me/companies/:id/decline", controllers.DeclineCompanyInvite)
+			protected.GET("/me/addresses", controllers.GetMyAddresses)
+			protected.POST("/me/addresses", controllers.CreateMyAddress)
+			protected.GET("/me/addresses/:id", controllers.GetMyAddress)
+			protected.PATCH("/me/addresses/:id", controllers.UpdateMyAddress)
+			protected.POST("/me/addresses/:id/default", controllers.SetMyDefaultAddress)
+			protected.DELETE("/me/addresses/:id", controllers.DeleteMyAddress)
+			protected.GET("/me/sessions", controllers.GetMySessions)
+			protected.DELETE("/me/sessions/:id", middlewares.DenyImpersonation(), controllers.RevokeMySession)
+			protected.GET("/me/2fa", controllers.GetMyTwoFactor)
+			protected.POST("/me/2fa/setup", middlewares.DenyImpersonation(), controllers.SetupMyTwoFactor)
+			protected.POST("/me/2fa/enable", middlewares.DenyImpersonation(), controllers.EnableMyTwoFactor)
+			protected.POST("/me/2fa/disable", middlewares.DenyImpersonation(), controllers.DisableMyTwoFactor)
+			protected.POST("/me/2fa/recovery-codes", middlewares.DenyImpersonation(), controllers.RegenerateMyRecoveryCodes)
+
+			// User (pemilik atau admin/staff)
+			protected.GET("/users/:id", middlewares.RequireSelfOrPermission("id", utils.PermUsersRead), controllers.GetUserByID)
+			protected.GET("/users/:id/is-active", middlewares.RequireSelfOrPermission("id", utils.PermUsersRead), controllers.CheckUserIsActive)
+			protected.PATCH("/users/:id", middlewares.DenyImpersonation(), middlewares.RequireSelfOrPermission("id", utils.PermUsersWrite), controllers.UpdateUser)
 
 			//user-account
-			protected.PATCH("/user-accounts/update-password", controllers.UpdatePasswordUserAccount)
-
-			// Categories (CRUD penuh)
-			protected.POST("/categories", controllers.CreateCategory)
-			protected.DELETE("/categories/:id", controllers.DeleteCategory)
-			protected.PATCH("/categories/:id", controllers.UpdateCategory)
-
-			// Products (CRUD penuh)
-			protected.POST("/products", controllers.CreateProduct)
-			protected.DELETE("/products/:id", controllers.DeleteProduct)
-			protected.PATCH("/products/:id", controllers.UpdateProduct)
-
-			// Orders
-			protected.GET("/orders", controllers.GetAllOrders)
-			protected.GET("/orders/dashboard", controllers.GetDashboard)
-			protected.GET("/orders/user/:userid", controllers.GetOrdersByUserID)
-			protected.GET("/orders/:orderid", controllers.GetOrderByID)
-			protected.GET("/orders/history/:userid", controllers.GetOrderHistoryByUserID)
-			protected.PATCH("/orders/:id/status", controllers.UpdateOrderStatusAndNotify)
+			protected.PATCH("/user-accounts/update-password", middlewares.DenyImpersonation(), controllers.UpdatePasswordUserAccount)
+
+			// Orders milik user (pemilik atau admin/staff)
+			protected.GET("/orders/user/:userid", middlewares.RequireSelfOrPermission("userid", utils.PermOrdersRead), controllers.GetOrdersByUserID)
+			protected.GET("/orders/:orderid", controllers.GetOrderByID) // cek kepemilikan di controller
+			protected.GET("/orders/history/:userid", middlewares.RequireSelfOrPermission("userid", utils.PermOrdersRead), controllers.GetOrderHistoryByUserID)
+
+			// Company B2B (anggota company atau staff, cek akses di controller)
+			protected.POST("/companies", controllers.CreateCompany)
+			protected.GET("/companies/:id", controllers.GetCompany)
+			protected.PATCH("/companies/:id", controllers.UpdateCompany)
+			protected.GET("/companies/:id/orders", controllers.GetCompanyOrders)
+			protected.GET("/companies/:id/notifications", controllers.GetCompanyNotifications)
+			protected.POST("/companies/:id/members", controllers.AddCompanyMember)
+			protected.PATCH("/companies/:id/members/:user_id", controllers.UpdateCompanyMember)
+			protected.DELETE("/companies/:id/members/:user_id", controllers.RemoveCompanyMember)
 
 			//notification
-			protected.GET("/notification", controllers.GetNotification)
-			protected.PATCH("/notification/:id/read", controllers.MarkNotificationAsRead)
-			protected.GET("/notification/admin", controllers.GetAdminNotifications)
+			protected.GET("/notification", middlewares.RequireSelfOrPermission("user_id", utils.PermOrdersRead), controllers.GetNotification)
+			protected.PATCH("/notification/:id/read", controllers.MarkNotificationAsRead) // cek kepemilikan di controller
+		}
+
+		// User management (admin / staff)
+		usersRead := protected.Group("/")
+		usersRead.Use(middlewares.RequirePermission(utils.PermUsersRead))
+		{
+			usersRead.GET("/users", controllers.GetUsers)
+			usersRead.GET("/admin/users/:id/crm", controllers.GetCustomerCRM)
+			usersRead.GET("/admin/tags", controllers.GetCustomerTags)
+			usersRead.GET("/admin/companies", controllers.GetCompanies)
+		}
+
+		usersWrite := protected.Group("/")
+		usersWrite.Use(middlewares.RequirePermission(utils.PermUsersWrite))
+		{
+			usersWrite.DELETE("/users/:id", controllers.DeleteUser)
+
+			// Lockout login (brute-force protection)
+			usersWrite.GET("/admin/lockouts", controllers.GetLoginLockouts)
+			usersWrite.DELETE("/admin/lockouts/:kind/:value", controllers.ClearLoginLockout)
+
+			// Sesi login user
+			usersWrite.DELETE("/admin/users/:id/sessions", controllers.RevokeUserSessions)
+
+			// Merge customer duplikat (guest checkout)
+			usersWrite.GET("/admin/users/duplicates", controllers.GetDuplicateUsers)
+			usersWrite.POST("/admin/users/:id/merge", controllers.MergeUsers)
+
+			// Tag CRM (didefinisikan admin)
+			usersWrite.POST("/admin/tags", controllers.CreateCustomerTag)
+			usersWrite.PATCH("/admin/tags/:id", controllers.UpdateCustomerTag)
+			usersWrite.DELETE("/admin/tags/:id", controllers.DeleteCustomerTag)
+		}
+
+		// CRM: tag & catatan internal customer (admin / sales)
+		crm := protected.Group("/admin")
+		crm.Use(middlewares.RequirePermission(utils.PermUsersCRM))
+		{
+			crm.PUT("/users/:id/tags", controllers.SetCustomerTags)
+			crm.POST("/users/:id/notes", controllers.CreateCustomerNote)
+			crm.DELETE("/users/:id/notes/:note_id", controllers.DeleteCustomerNote)
+		}
+
+		// Impersonation customer untuk support
+		impersonate := protected.Group("/admin")
+		impersonate.Use(middlewares.RequirePermission(utils.PermUsersImpersonate))
+		{
+			impersonate.POST("/users/:id/impersonate", controllers.StartImpersonation)
+		}
+
+		// Data pribadi customer: export & hapus (UU PDP)
+		privacy := protected.Group("/admin")
+		privacy.Use(middlewares.RequirePermission(utils.PermUsersPrivacy))
+		{
+			privacy.GET("/users/:id/export", controllers.ExportUserData)
+			privacy.POST("/users/:id/erase", controllers.EraseUserData)
+		}
+
+		// Staff & role (admin)
+		staff := protected.Group("/admin")
+		staff.Use(middlewares.RequirePermission(utils.PermStaffManage))
+		{
+			staff.GET("/staff", controllers.GetStaff)
+			staff.POST("/staff/invite", controllers.InviteStaff)
+			staff.PATCH("/staff/:id/role", controllers.UpdateStaffRole)
+			staff.POST("/staff/:id/deactivate", controllers.DeactivateStaff)
+			staff.POST("/staff/:id/reactivate", controllers.ReactivateStaff)
+			staff.DELETE("/staff/:id/2fa", controllers.ResetStaffTwoFactor)
+			staff.GET("/roles", controllers.GetRoles)
+			staff.GET("/permissions", controllers.GetPermissions)
+			staff.PUT("/roles/:name/permissions", controllers.UpdateRolePermissions)
+
+			// API key untuk client mesin
+			staff.GET("/api-keys", controllers.GetAPIKeys)
+			staff.POST("/api-keys", controllers.CreateAPIKey)
+			staff.DELETE("/api-keys/:id", controllers.RevokeAPIKey)
+
+			staff.GET("/audit-logs", controllers.GetAuditLogs)
+		}
+
+		// Katalog (CRUD penuh categories & products)
+		catalog := protected.Group("/")
+		catalog.Use(middlewares.RequirePermission(utils.PermCatalogWrite))
+		{
+			catalog.POST("/categories", controllers.CreateCategory)
+			catalog.DELETE("/categories/:id", controllers.DeleteCategory)
+			catalog.PATCH("/categories/:id", controllers.UpdateCategory)
+
+			catalog.POST("/products", controllers.CreateProduct)
+			catalog.DELETE("/products/:id", controllers.DeleteProduct)
+			catalog.PATCH("/products/:id", controllers.UpdateProduct)
+
+			// harga: riwayat, ubah / jadwalkan, batalkan jadwal
+			catalog.GET("/products/:id/prices", controllers.GetProductPrices)
+			catalog.POST("/products/:id/prices", controllers.SetProductPrice)
+			catalog.DELETE("/products/:id/prices/:price_id", controllers.CancelProductPrice)
+
+			// pilihan (warna, ketebalan) & varian SKU
+			catalog.PUT("/products/:id/options", controllers.SetProductOptions)
+			catalog.POST("/products/:id/variants", controllers.CreateProductVariant)
+			catalog.PATCH("/products/:id/variants/:variant_id", controllers.UpdateProductVariant)
+			catalog.DELETE("/products/:id/variants/:variant_id", controllers.DeleteProductVariant)
+
+			// rumus harga ukuran custom (lebar x tinggi)
+			catalog.GET("/products/:id/formula", controllers.GetPricingFormula)
+			catalog.PUT("/products/:id/formula", controllers.SetPricingFormula)
+			catalog.DELETE("/products/:id/formula", controllers.DeletePricingFormula)
+		}
 
-			// Info
+		// Order management
+		ordersRead := protected.Group("/")
+		ordersRead.Use(middlewares.RequirePermission(utils.PermOrdersRead))
+		{
+			ordersRead.GET("/orders", controllers.GetAllOrders)
+			ordersRead.GET("/orders/dashboard", controllers.GetDashboard)
+			ordersRead.GET("/notification/admin", controllers.GetAdminNotifications)
+		}
 
-			protected.PUT("/info", controllers.UpsertInfo)
+		ordersWrite := protected.Group("/")
+		ordersWrite.Use(middlewares.RequirePermission(utils.PermOrdersWrite))
+		{
+			ordersWrite.PATCH("/orders/:id/status", controllers.UpdateOrderStatusAndNotify)
+		}
 
+		// Info
+		info := protected.Group("/")
+		info.Use(middlewares.RequirePermission(utils.PermInfoWrite))
+		{
+			info.PUT("/info", controllers.UpsertInfo)
 		}
 	}
 
-	// SSE
+	// Public key untuk verifikasi JWT oleh service lain
+	r.GET("/.well-known/jwks.json", controllers.GetJWKS)
+
+	// SSE (auth lewat header, cookie atau ticket)
 	r.GET("/events", sse.GinHandler)
 }
@@ -1,9 +1,20 @@
 package sse
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
 
+	"github.com/ary/go-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GinHandler membuka stream SSE. Identitas diambil dari token
+// (header Authorization, cookie access_token, atau ?ticket=).
 func GinHandler(c *gin.Context) {
-	userID := c.Query("user_id") // contoh: ?user_id=UUID
-	role := c.Query("role")      // contoh: ?role=admin
-	ServeHTTP(c.Writer, c.Request, userID, role)
+	claims, _, err := utils.AuthenticateStream(c)
+	if err != nil || !utils.StreamStillValid(claims) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ServeHTTP(c.Writer, c.Request, utils.NewStreamAuth(claims))
 }
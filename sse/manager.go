@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/ary/go-api/utils"
 )
 
 type SSEClient struct {
@@ -105,7 +107,7 @@ func BroadcastToUser(userID string, message string) {
 }
 
 // --- HTTP handler (net/http), dipakai juga oleh Gin) ---
-func ServeHTTP(w http.ResponseWriter, r *http.Request, userID, role string) {
+func ServeHTTP(w http.ResponseWriter, r *http.Request, auth utils.StreamAuth) {
 	// Header SSE
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -124,8 +126,8 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, userID, role string) {
 	}
 
 	client := &SSEClient{
-		UserID: userID,
-		Role:   role,
+		UserID: auth.UserID,
+		Role:   auth.Role,
 		ch:     make(chan string, 8),
 	}
 	addClient(client)
@@ -139,6 +141,12 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, userID, role string) {
 	heartbeat := time.NewTicker(25 * time.Second)
 	defer heartbeat.Stop()
 
+	authCheck := time.NewTicker(utils.StreamAuthCheckInterval)
+	defer authCheck.Stop()
+
+	expired := time.NewTimer(time.Until(auth.ExpiresAt))
+	defer expired.Stop()
+
 	ctx := r.Context()
 	for {
 		select {
@@ -150,8 +158,23 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, userID, role string) {
 			// komentar SSE (heartbeat)
 			fmt.Fprintf(w, ": ping\n\n")
 			flusher.Flush()
+		case <-authCheck.C:
+			if auth.StillValid != nil && !auth.StillValid() {
+				closeUnauthorized(w, flusher, "token revoked")
+				return
+			}
+		case <-expired.C:
+			closeUnauthorized(w, flusher, "token expired")
+			return
 		case <-ctx.Done():
 			return
 		}
 	}
 }
+
+// closeUnauthorized memberi tahu client kenapa koneksi ditutup,
+// supaya client refresh token / minta ticket baru sebelum reconnect
+func closeUnauthorized(w http.ResponseWriter, flusher http.Flusher, reason string) {
+	fmt.Fprintf(w, "event: unauthorized\ndata: %s\n\n", reason)
+	flusher.Flush()
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+)
+
+// Format API key: gak_<prefix>_<secret>
+// prefix (hex) dipakai untuk lookup di DB, secret hanya disimpan sebagai hash.
+const (
+	apiKeyTag            = "gak"
+	apiKeyLastUsedPeriod = time.Minute // last_used_at tidak ditulis di setiap request
+)
+
+var (
+	ErrAPIKeyInvalid = errors.New("invalid api key")
+	ErrAPIKeyExpired = errors.New("api key expired")
+	ErrAPIKeyRevoked = errors.New("api key revoked")
+)
+
+// GenerateAPIKey membuat key baru. Return key lengkap (ditampilkan sekali), prefix dan hash.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	b := make([]byte, 4)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	prefix = hex.EncodeToString(b)
+
+	secret, err := RandomToken(32)
+	if err != nil {
+		return
+	}
+
+	key = apiKeyTag + "_" + prefix + "_" + secret
+	hash = HashToken(key)
+	return
+}
+
+// ValidateAPIKey mencari key berdasarkan prefix lalu mencocokkan hash-nya
+func ValidateAPIKey(key, ip string) (*models.APIKey, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyTag || parts[1] == "" {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	var apiKey models.APIKey
+	if err := config.DB.Where("prefix = ?", parts[1]).First(&apiKey).Error; err != nil {
+		return nil, ErrAPIKeyInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(HashToken(key))) != 1 {
+		return nil, ErrAPIKeyInvalid
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+	if apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(time.Now()) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > apiKeyLastUsedPeriod {
+		now := time.Now()
+		err := config.DB.Model(&apiKey).UpdateColumns(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": ip,
+		}).Error
+		if err != nil {
+			log.Println("⚠️ Failed to update api key last_used_at:", err)
+		}
+		apiKey.LastUsedAt = &now
+		apiKey.LastUsedIP = ip
+	}
+
+	return &apiKey, nil
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WriteAuditLog menyimpan 1 entri audit. details di-encode ke JSON.
+// Gagal menulis audit hanya di-log, tidak menggagalkan request.
+func WriteAuditLog(entry models.AuditLog, details interface{}) {
+	if err := CreateAuditLog(config.DB, entry, details); err != nil {
+		log.Printf("❌ Failed to write audit log action=%s: %v", entry.Action, err)
+	}
+}
+
+// CreateAuditLog menyimpan entri audit lewat db / tx yang diberikan,
+// dipakai kalau audit harus ikut commit / rollback bersama perubahan datanya.
+func CreateAuditLog(db *gorm.DB, entry models.AuditLog, details interface{}) error {
+	if details != nil {
+		data, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+		entry.Details = string(data)
+	}
+	return db.Create(&entry).Error
+}
+
+// AuditFromContext mengisi actor, method, path dan IP dari request yang sedang berjalan.
+// Kalau request memakai token impersonation, actor-nya adalah staff aslinya.
+func AuditFromContext(c *gin.Context, action string) models.AuditLog {
+	entry := models.AuditLog{
+		Action:    action,
+		ActorID:   ParseUUID(c.GetString("user_id")),
+		ActorRole: c.GetString("role"),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		IP:        c.ClientIP(),
+	}
+
+	value, _ := c.Get("claims")
+	if claims, ok := value.(*Claims); ok && claims.Act != nil {
+		entry.ActorID = ParseUUID(claims.Act.Sub)
+		entry.ActorRole = claims.Act.Role
+		entry.SubjectID = ParseUUID(claims.UserID)
+	}
+	return entry
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Penyimpanan data singkat (ticket, token 2FA) di Redis dengan fallback memory.
+var ephemeral = &ephemeralStore{entries: make(map[string]ephemeralEntry)}
+
+type ephemeralStore struct {
+	mu      sync.Mutex
+	entries map[string]ephemeralEntry
+}
+
+type ephemeralEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+func (s *ephemeralStore) put(key string, data []byte, ttl time.Duration) {
+	if config.RedisClient != nil {
+		err := config.RedisClient.Set(context.Background(), key, data, ttl).Err()
+		if err == nil {
+			return
+		}
+		log.Println("⚠️ Redis ephemeral write failed, fallback to memory:", err)
+	}
+
+	s.sweep()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = ephemeralEntry{data: data, expiresAt: time.Now().Add(ttl)}
+}
+
+// sweep menghapus entry memory yang sudah expired
+func (s *ephemeralStore) sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, e := range s.entries {
+		if now.After(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+}
+
+// get membaca data; kalau take=true data langsung dihapus (sekali pakai)
+func (s *ephemeralStore) get(key string, take bool) ([]byte, bool) {
+	if config.RedisClient != nil {
+		ctx := context.Background()
+		var (
+			data []byte
+			err  error
+		)
+		if take {
+			data, err = config.RedisClient.GetDel(ctx, key).Bytes()
+		} else {
+			data, err = config.RedisClient.Get(ctx, key).Bytes()
+		}
+		if err == nil {
+			return data, true
+		}
+		if !errors.Is(err, redis.Nil) {
+			log.Println("⚠️ Redis ephemeral read failed, fallback to memory:", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if take || (ok && time.Now().After(e.expiresAt)) {
+		delete(s.entries, key)
+	}
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, false
+	}
+	return e.data, true
+}
+
+func (s *ephemeralStore) del(key string) {
+	if config.RedisClient != nil {
+		if err := config.RedisClient.Del(context.Background(), key).Err(); err != nil {
+			log.Println("⚠️ Redis ephemeral del failed:", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// RunMemorySweep membersihkan fallback memory (login guard, rate limit, session touch, ephemeral)
+// yang sudah expired. Entry yang tidak pernah dibaca lagi tidak terhapus di get, jadi perlu disapu
+// berkala supaya memory tidak terus bertambah saat Redis mati. Dijalankan sebagai goroutine dari main.
+func RunMemorySweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		guardStore.sweep()
+		sweepSessionTouches()
+		ephemeral.sweep()
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var jwtKey = []byte("SECRET_KEY_YANG_AMAN") // sebaiknya pakai os.Getenv()
 var ErrTokenExpired = errors.New("token expired")
 
+// Access token dibuat singkat, diperpanjang lewat refresh token
+const AccessTokenTTL = 15 * time.Minute
+
 type Claims struct {
 	UserID   string `json:"user_id"`
 	Phone    string `json:"phone"`
@@ -23,13 +26,22 @@ type Claims struct {
 	PhotoUrl string `json:"photo_url"`
 	Role     string `json:"role"`
 	IsActive bool   `json:"is_active"`
+	// SessionID = family refresh token dari login yang menerbitkan token ini
+	SessionID string `json:"sid,omitempty"`
+	// Act diisi kalau token ini hasil impersonation (RFC 8693 actor claim)
+	Act *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Actor adalah staff yang sedang memakai identitas user lain
+type Actor struct {
+	Sub  string `json:"sub"`
+	Role string `json:"role,omitempty"`
+}
+
 // GenerateJWT membuat token JWT dengan semua info user
 func GenerateJWT(userID, phone, name, email, address, regency, district, lang, lat, photoUrl, role string) (string, error) {
-	expirationTime := time.Now().Add(7 * 24 * time.Hour) // 1 hari
-	claims := &Claims{
+	return GenerateAccessToken(&Claims{
 		UserID:   userID,
 		Phone:    phone,
 		Name:     name,
@@ -41,14 +53,31 @@ func GenerateJWT(userID, phone, name, email, address, regency, district, lang, l
 		Lat:      lat,
 		PhotoUrl: photoUrl,
 		Role:     role,
+	})
+}
+
+// GenerateAccessToken menandatangani claims dengan masa berlaku AccessTokenTTL dan jti baru
+func GenerateAccessToken(claims *Claims) (string, error) {
+	return GenerateAccessTokenWithTTL(claims, AccessTokenTTL)
+}
+
+// GenerateAccessTokenWithTTL sama seperti GenerateAccessToken dengan masa berlaku sendiri
+func GenerateAccessTokenWithTTL(claims *Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		Subject:   claims.UserID,
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
 
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	key := keyRing().signingKey()
+	if key == nil {
+		return "", errors.New("no active JWT signing key")
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.signKey)
 }
 
 // ValidateJWT validasi & ambil klaim dari token
@@ -56,9 +85,9 @@ func ValidateJWT(tokenStr string) (*Claims, error) {
 	claims := &Claims{}
 
 	// parsing token dengan Claims
-	_, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
-	})
+	// key dipilih berdasarkan header kid (lihat jwt_keys.go)
+	_, err := jwt.ParseWithClaims(tokenStr, claims, keyRing().lookup,
+		jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}))
 
 	if err != nil {
 		// cek apakah error karena expired
@@ -73,5 +102,10 @@ func ValidateJWT(tokenStr string) (*Claims, error) {
 		return nil, ErrTokenExpired
 	}
 
+	// cek revocation list (logout / token dicabut)
+	if IsAccessTokenRevoked(claims.ID) || IsRefreshFamilyRevoked(claims.SessionID) {
+		return nil, ErrTokenRevoked
+	}
+
 	return claims, nil
 }
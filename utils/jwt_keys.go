@@ -0,0 +1,278 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ary/go-api/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key ring JWT. Sumber key:
+//   - JWT_KEYS_DIR : folder berisi <kid>.pem (RSA / Ed25519, private atau public saja)
+//     dan <kid>.key (secret HS256)
+//   - JWT_SECRET   : secret HS256 dari env, kid diambil dari JWT_SECRET_KID
+//
+// JWT_ACTIVE_KID menentukan key untuk sign. Key lain tetap dipakai untuk verifikasi,
+// jadi key yang sudah dipensiunkan cukup dibiarkan di folder sampai token terakhirnya expired.
+const (
+	legacyKid = "legacy"
+	// panjang minimum secret HS256 (JWT_SECRET / <kid>.key)
+	minHMACSecretLength = 32
+)
+
+var legacyJWTKey = []byte("SECRET_KEY_YANG_AMAN") // hanya untuk dev mode (APP_ENV=development) tanpa key
+
+type JWTKey struct {
+	Kid       string
+	Method    jwt.SigningMethod
+	signKey   interface{} // nil kalau verify-only
+	verifyKey interface{}
+}
+
+type KeyRing struct {
+	mu        sync.RWMutex
+	keys      map[string]*JWTKey
+	activeKid string
+}
+
+var (
+	jwtKeys     = &KeyRing{keys: make(map[string]*JWTKey)}
+	jwtKeysOnce sync.Once
+	jwtKeysErr  error
+)
+
+// LoadJWTKeys memuat key ring dari env / file. Dipanggil sekali saat startup.
+func LoadJWTKeys() error {
+	jwtKeysOnce.Do(func() {
+		jwtKeysErr = jwtKeys.load()
+	})
+	return jwtKeysErr
+}
+
+func keyRing() *KeyRing {
+	if err := LoadJWTKeys(); err != nil {
+		log.Println("❌ Failed to load JWT keys:", err)
+	}
+	return jwtKeys
+}
+
+func (k *KeyRing) load() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("read JWT_KEYS_DIR: %w", err)
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			ext := filepath.Ext(e.Name())
+			kid := strings.TrimSuffix(e.Name(), ext)
+
+			var key *JWTKey
+			switch ext {
+			case ".pem":
+				key, err = parsePEMKey(kid, path)
+			case ".key":
+				key, err = parseHMACKeyFile(kid, path)
+			default:
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("load key %s: %w", e.Name(), err)
+			}
+			k.keys[kid] = key
+		}
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		if len(secret) < minHMACSecretLength {
+			return fmt.Errorf("JWT_SECRET must be at least %d bytes", minHMACSecretLength)
+		}
+		kid := os.Getenv("JWT_SECRET_KID")
+		if kid == "" {
+			kid = "hs256-env"
+		}
+		k.keys[kid] = &JWTKey{Kid: kid, Method: jwt.SigningMethodHS256, signKey: []byte(secret), verifyKey: []byte(secret)}
+	}
+
+	if len(k.keys) == 0 {
+		if !config.DevMode() {
+			return errors.New("no JWT keys configured, set JWT_KEYS_DIR or JWT_SECRET")
+		}
+		log.Println("⚠️ No JWT keys configured (JWT_KEYS_DIR / JWT_SECRET), using legacy built-in key")
+		k.keys[legacyKid] = &JWTKey{Kid: legacyKid, Method: jwt.SigningMethodHS256, signKey: legacyJWTKey, verifyKey: legacyJWTKey}
+	}
+
+	k.activeKid = os.Getenv("JWT_ACTIVE_KID")
+	if k.activeKid == "" {
+		// tanpa JWT_ACTIVE_KID hanya boleh ada 1 key yang bisa sign
+		for kid, key := range k.keys {
+			if key.signKey == nil {
+				continue
+			}
+			if k.activeKid != "" {
+				return errors.New("multiple signing keys found, set JWT_ACTIVE_KID")
+			}
+			k.activeKid = kid
+		}
+	}
+
+	active, ok := k.keys[k.activeKid]
+	if !ok || active.signKey == nil {
+		return fmt.Errorf("active JWT key %q not found or has no private key", k.activeKid)
+	}
+
+	log.Printf("🔑 JWT keys loaded: %d key(s), active kid=%s (%s)", len(k.keys), k.activeKid, active.Method.Alg())
+	return nil
+}
+
+func parseHMACKeyFile(kid, path string) (*JWTKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	secret := []byte(strings.TrimSpace(string(data)))
+	if len(secret) < minHMACSecretLength {
+		return nil, fmt.Errorf("HS256 secret must be at least %d bytes", minHMACSecretLength)
+	}
+	return &JWTKey{Kid: kid, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}, nil
+}
+
+func parsePEMKey(kid, path string) (*JWTKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &JWTKey{Kid: kid, Method: jwt.SigningMethodRS256, signKey: key, verifyKey: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &JWTKey{Kid: kid, Method: jwt.SigningMethodRS256, verifyKey: key}, nil
+	case ed25519.PrivateKey:
+		return &JWTKey{Kid: kid, Method: jwt.SigningMethodEdDSA, signKey: key, verifyKey: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &JWTKey{Kid: kid, Method: jwt.SigningMethodEdDSA, verifyKey: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
+
+// signingKey mengembalikan key aktif untuk sign token baru
+func (k *KeyRing) signingKey() *JWTKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys[k.activeKid]
+}
+
+// lookup dipakai saat validasi token (jwt.Keyfunc)
+func (k *KeyRing) lookup(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		// token lama tanpa kid hanya valid kalau masih pakai legacy key
+		kid = legacyKid
+	}
+
+	k.mu.RLock()
+	key, ok := k.keys[kid]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	// cegah algorithm confusion (misal token HS256 dengan kid RSA)
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for kid %q", token.Method.Alg(), kid)
+	}
+	return key.verifyKey, nil
+}
+
+// JWK adalah format public key untuk /.well-known/jwks.json
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// PublicJWKs mengembalikan semua public key asimetris (HS256 tidak pernah diekspos)
+func PublicJWKs() []JWK {
+	k := keyRing()
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	kids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := []JWK{}
+	for _, kid := range kids {
+		key := k.keys[kid]
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, JWK{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks = append(jwks, JWK{
+				Kty: "OKP",
+				Kid: kid,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return jwks
+}
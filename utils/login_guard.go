@@ -0,0 +1,264 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Proteksi brute-force login: counter gagal per nomor HP dan per IP.
+// Counter disimpan di Redis, fallback ke memory kalau Redis mati.
+const (
+	LoginFailureWindow = 15 * time.Minute
+	LoginLockDuration  = 15 * time.Minute
+	MaxPhoneFailures   = 5
+	MaxIPFailures      = 20
+	maxLoginDelay      = 5 * time.Second
+)
+
+const (
+	LockoutKindPhone = "phone"
+	LockoutKindIP    = "ip"
+)
+
+type LoginLockout struct {
+	Kind        string    `json:"kind"`
+	Value       string    `json:"value"`
+	Failures    int64     `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func loginFailKey(kind, value string) string {
+	return "login_fail:" + kind + ":" + lockoutValue(kind, value)
+}
+
+func loginLockKey(kind, value string) string {
+	return "login_lock:" + kind + ":" + lockoutValue(kind, value)
+}
+
+// lockoutValue nomor HP selalu disimpan dalam format E.164, jadi login, 2FA dan
+// admin unlock memakai key yang sama walaupun nomornya diketik beda format
+func lockoutValue(kind, value string) string {
+	if kind == LockoutKindPhone {
+		return NormalizePhoneOrRaw(value)
+	}
+	return value
+}
+
+// LoginLockedUntil cek apakah nomor HP atau IP sedang dikunci
+func LoginLockedUntil(phone, ip string) (time.Time, bool) {
+	var until time.Time
+	for _, key := range []string{loginLockKey(LockoutKindPhone, phone), loginLockKey(LockoutKindIP, ip)} {
+		if _, ttl, ok := guardStore.get(key); ok {
+			if t := time.Now().Add(ttl); t.After(until) {
+				until = t
+			}
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// RegisterLoginFailure menambah counter gagal dan mengunci kalau melewati batas.
+// Return jumlah gagal terbanyak (dipakai untuk progressive delay).
+func RegisterLoginFailure(phone, ip string) int64 {
+	phoneFails := guardStore.incr(loginFailKey(LockoutKindPhone, phone), LoginFailureWindow)
+	ipFails := guardStore.incr(loginFailKey(LockoutKindIP, ip), LoginFailureWindow)
+
+	if phoneFails >= MaxPhoneFailures {
+		guardStore.set(loginLockKey(LockoutKindPhone, phone), phoneFails, LoginLockDuration)
+		log.Printf("🔒 Login locked for phone=%s after %d failures", phone, phoneFails)
+	}
+	if ipFails >= MaxIPFailures {
+		guardStore.set(loginLockKey(LockoutKindIP, ip), ipFails, LoginLockDuration)
+		log.Printf("🔒 Login locked for ip=%s after %d failures", ip, ipFails)
+	}
+
+	if phoneFails > ipFails {
+		return phoneFails
+	}
+	return ipFails
+}
+
+// ResetLoginFailures dipanggil setelah login berhasil
+func ResetLoginFailures(phone string) {
+	guardStore.del(loginFailKey(LockoutKindPhone, phone))
+}
+
+// LoginDelay menghitung jeda progresif: 2 gagal pertama tanpa jeda, lalu 500ms, 1s, 2s, ... maks 5s
+func LoginDelay(failures int64) time.Duration {
+	if failures <= 2 {
+		return 0
+	}
+	delay := 250 * time.Millisecond << uint(failures-2)
+	if delay > maxLoginDelay || delay <= 0 {
+		return maxLoginDelay
+	}
+	return delay
+}
+
+// ListLoginLockouts mengembalikan semua kunci login yang masih aktif
+func ListLoginLockouts() []LoginLockout {
+	lockouts := []LoginLockout{}
+	for _, key := range guardStore.keys("login_lock:") {
+		parts := strings.SplitN(strings.TrimPrefix(key, "login_lock:"), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		failures, ttl, ok := guardStore.get(key)
+		if !ok {
+			continue
+		}
+		lockouts = append(lockouts, LoginLockout{
+			Kind:        parts[0],
+			Value:       parts[1],
+			Failures:    failures,
+			LockedUntil: time.Now().Add(ttl),
+		})
+	}
+	sort.Slice(lockouts, func(i, j int) bool { return lockouts[i].LockedUntil.After(lockouts[j].LockedUntil) })
+	return lockouts
+}
+
+// ClearLoginLockout membuka kunci + reset counter untuk nomor HP / IP
+func ClearLoginLockout(kind, value string) {
+	guardStore.del(loginLockKey(kind, value), loginFailKey(kind, value))
+}
+
+// --- storage: Redis dengan fallback memory ---
+
+type loginGuardStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryCounter
+}
+
+type memoryCounter struct {
+	value     int64
+	expiresAt time.Time
+}
+
+var guardStore = &loginGuardStore{entries: make(map[string]memoryCounter)}
+
+func (s *loginGuardStore) incr(key string, ttl time.Duration) int64 {
+	if config.RedisClient != nil {
+		ctx := context.Background()
+		n, err := config.RedisClient.Incr(ctx, key).Result()
+		if err == nil {
+			if n == 1 {
+				config.RedisClient.Expire(ctx, key, ttl)
+			}
+			return n
+		}
+		log.Println("⚠️ Redis login guard incr failed, fallback to memory:", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok || time.Now().After(e.expiresAt) {
+		e = memoryCounter{expiresAt: time.Now().Add(ttl)}
+	}
+	e.value++
+	s.entries[key] = e
+	return e.value
+}
+
+func (s *loginGuardStore) set(key string, value int64, ttl time.Duration) {
+	if config.RedisClient != nil {
+		err := config.RedisClient.Set(context.Background(), key, value, ttl).Err()
+		if err == nil {
+			return
+		}
+		log.Println("⚠️ Redis login guard set failed, fallback to memory:", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryCounter{value: value, expiresAt: time.Now().Add(ttl)}
+}
+
+func (s *loginGuardStore) get(key string) (int64, time.Duration, bool) {
+	if config.RedisClient != nil {
+		ctx := context.Background()
+		val, err := config.RedisClient.Get(ctx, key).Result()
+		if err == nil {
+			ttl, _ := config.RedisClient.TTL(ctx, key).Result()
+			n, _ := strconv.ParseInt(val, 10, 64)
+			return n, ttl, ttl > 0
+		}
+		// redis.Nil: bisa jadi kunci dibuat waktu Redis mati, cek memory juga
+		if !errors.Is(err, redis.Nil) {
+			log.Println("⚠️ Redis login guard get failed, fallback to memory:", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok || time.Now().After(e.expiresAt) {
+		delete(s.entries, key)
+		return 0, 0, false
+	}
+	return e.value, time.Until(e.expiresAt), true
+}
+
+func (s *loginGuardStore) del(keys ...string) {
+	if config.RedisClient != nil {
+		if err := config.RedisClient.Del(context.Background(), keys...).Err(); err != nil {
+			log.Println("⚠️ Redis login guard del failed:", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+}
+
+// sweep menghapus counter memory yang sudah expired
+func (s *loginGuardStore) sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for key, e := range s.entries {
+		if now.After(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+func (s *loginGuardStore) keys(prefix string) []string {
+	seen := map[string]struct{}{}
+
+	if config.RedisClient != nil {
+		iter := config.RedisClient.Scan(context.Background(), 0, prefix+"*", 100).Iterator()
+		for iter.Next(context.Background()) {
+			seen[iter.Val()] = struct{}{}
+		}
+		if err := iter.Err(); err != nil {
+			log.Println("⚠️ Redis login guard scan failed:", err)
+		}
+	}
+
+	s.mu.Lock()
+	for key, e := range s.entries {
+		if strings.HasPrefix(key, prefix) && time.Now().Before(e.expiresAt) {
+			seen[key] = struct{}{}
+		}
+	}
+	s.mu.Unlock()
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Token 2FA: diberikan setelah password (atau OTP HP) benar, dipakai di langkah
+// kedua login. Bukan access token, jadi tidak bisa dipakai untuk API lain.
+const MFATokenTTL = 5 * time.Minute
+
+type MFAChallenge struct {
+	AccountID string `json:"account_id"`
+	Phone     string `json:"phone"`
+}
+
+func redisKeyMFAToken(hash string) string { return "mfa_token:" + hash }
+
+// IssueMFAToken membuat token langkah kedua login untuk account
+func IssueMFAToken(challenge MFAChallenge) (string, error) {
+	token, err := RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return "", err
+	}
+	ephemeral.put(redisKeyMFAToken(HashToken(token)), data, MFATokenTTL)
+	return token, nil
+}
+
+// LookupMFAToken membaca challenge tanpa menghapusnya (kode salah boleh dicoba lagi)
+func LookupMFAToken(token string) (*MFAChallenge, bool) {
+	data, ok := ephemeral.get(redisKeyMFAToken(HashToken(token)), false)
+	if !ok {
+		return nil, false
+	}
+	var challenge MFAChallenge
+	if err := json.Unmarshal(data, &challenge); err != nil {
+		return nil, false
+	}
+	return &challenge, true
+}
+
+// ConsumeMFAToken menghapus token setelah langkah kedua berhasil
+func ConsumeMFAToken(token string) {
+	ephemeral.del(redisKeyMFAToken(HashToken(token)))
+}
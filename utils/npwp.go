@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidNPWP = errors.New("invalid NPWP")
+
+// NormalizeNPWP membuang titik / strip dari NPWP. Format lama 15 digit
+// (01.234.567.8-901.000), format baru 16 digit (sama dengan NIK).
+func NormalizeNPWP(raw string) (string, error) {
+	var digits strings.Builder
+	for _, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '.' || r == '-' || r == ' ':
+		default:
+			return "", ErrInvalidNPWP
+		}
+	}
+	npwp := digits.String()
+	if len(npwp) != 15 && len(npwp) != 16 {
+		return "", ErrInvalidNPWP
+	}
+	return npwp, nil
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"log"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+)
+
+// DeleteExpiredQuotes menghapus quote yang sudah expired dan tidak pernah dipakai order.
+// Quote yang sudah jadi order tetap disimpan sebagai rincian harga order.
+func DeleteExpiredQuotes() (int64, error) {
+	res := config.DB.Where("order_id IS NULL AND expires_at <= ?", time.Now()).Delete(&models.OrderQuote{})
+	return res.RowsAffected, res.Error
+}
+
+// RunQuoteCleanup membersihkan quote expired secara berkala. Dijalankan sebagai goroutine dari main.
+func RunQuoteCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := DeleteExpiredQuotes(); err != nil {
+			log.Println("❌ Failed to delete expired quotes:", err)
+		} else if n > 0 {
+			log.Printf("🧹 Deleted %d expired quotes", n)
+		}
+		<-ticker.C
+	}
+}
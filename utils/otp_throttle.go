@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+// Batas permintaan OTP per nomor HP. Counter disimpan di store login guard dan dicatat
+// untuk semua nomor (terdaftar atau tidak), jadi 429 tidak membocorkan nomor mana yang terdaftar.
+var (
+	ErrOTPResendTooSoon = errors.New("otp requested too soon")
+	ErrOTPHourlyLimit   = errors.New("too many otp requests")
+)
+
+func otpResendKey(phone string) string { return "otp_resend:" + phone }
+func otpHourlyKey(phone string) string { return "otp_hourly:" + phone }
+
+// ThrottleOTPRequest mencatat satu permintaan OTP untuk nomor HP. Return error kalau
+// permintaan terakhir belum lewat resendInterval atau sudah maxPerHour kali dalam 1 jam.
+func ThrottleOTPRequest(phone string, resendInterval time.Duration, maxPerHour int64) error {
+	if guardStore.incr(otpResendKey(phone), resendInterval) > 1 {
+		return ErrOTPResendTooSoon
+	}
+	if guardStore.incr(otpHourlyKey(phone), time.Hour) > maxPerHour {
+		return ErrOTPHourlyLimit
+	}
+	return nil
+}
@@ -1,19 +1,99 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func HashPassword(pw string) string {
-	hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
-	if err != nil {
-		return ""
+// Hash password memakai format PHC ($argon2id$v=19$m=..,t=..,p=..$<salt>$<hash>),
+// jadi algoritma & parameter tersimpan di hash-nya. Hash bcrypt lama ($2a$...) masih
+// bisa dipakai login lalu di-rehash. Kalau parameter di bawah dinaikkan, hash lama otomatis di-rehash saat login berikutnya.
+type argon2Params struct {
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+var currentArgon2 = argon2Params{Memory: 64 * 1024, Time: 3, Threads: 4, SaltLen: 16, KeyLen: 32}
+
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
+// HashPassword membuat hash argon2id dari password
+func HashPassword(pw string) (string, error) {
+	p := currentArgon2
+	salt := make([]byte, p.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
 	}
-	return string(hash)
+
+	key := argon2.IDKey([]byte(pw), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
 }
 
 // Fungsi untuk memeriksa apakah password sesuai dengan hash
 func CheckPasswordHash(hashedPwd, plainPwd string) bool {
+	if strings.HasPrefix(hashedPwd, "$argon2id$") {
+		p, salt, key, err := parseArgon2Hash(hashedPwd)
+		if err != nil {
+			return false
+		}
+		other := argon2.IDKey([]byte(plainPwd), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+		return subtle.ConstantTimeCompare(key, other) == 1
+	}
+
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPwd), []byte(plainPwd))
 	return err == nil
 }
+
+// PasswordNeedsRehash true kalau hash masih bcrypt atau argon2id dengan parameter lama
+func PasswordNeedsRehash(hashedPwd string) bool {
+	if !strings.HasPrefix(hashedPwd, "$argon2id$") {
+		return true
+	}
+	p, _, _, err := parseArgon2Hash(hashedPwd)
+	return err != nil || p != currentArgon2
+}
+
+func parseArgon2Hash(hash string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+
+	// ["", "argon2id", "v=19", "m=..,t=..,p=..", salt, key]
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil || p.Memory == 0 || p.Time == 0 || p.Threads == 0 {
+		return p, nil, nil, ErrInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrInvalidPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, ErrInvalidPasswordHash
+	}
+
+	p.SaltLen = uint32(len(salt))
+	p.KeyLen = uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// argon2Hash hash PHC dengan parameter tertentu (untuk hash "lama")
+func argon2Hash(pw string, p argon2Params) string {
+	salt := []byte(strings.Repeat("s", int(p.SaltLen)))
+	key := argon2.IDKey([]byte(pw), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func TestHashPasswordPHC(t *testing.T) {
+	hash, err := HashPassword("rahasia-kaca-99")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$", argon2.Version, currentArgon2.Memory, currentArgon2.Time, currentArgon2.Threads)
+	if !strings.HasPrefix(hash, prefix) {
+		t.Fatalf("hash %q does not start with %q", hash, prefix)
+	}
+
+	p, salt, key, err := parseArgon2Hash(hash)
+	if err != nil {
+		t.Fatalf("parseArgon2Hash: %v", err)
+	}
+	if p != currentArgon2 {
+		t.Errorf("parsed params = %+v, want %+v", p, currentArgon2)
+	}
+	if len(salt) != int(currentArgon2.SaltLen) || len(key) != int(currentArgon2.KeyLen) {
+		t.Errorf("salt/key length = %d/%d", len(salt), len(key))
+	}
+
+	other, _ := HashPassword("rahasia-kaca-99")
+	if other == hash {
+		t.Error("same password produced the same hash (salt not random)")
+	}
+}
+
+func TestParseArgon2HashInvalid(t *testing.T) {
+	valid := argon2Hash("pw", argon2Params{Memory: 1024, Time: 1, Threads: 1, SaltLen: 8, KeyLen: 16})
+	parts := strings.Split(valid, "$")
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"empty", ""},
+		{"bcrypt", "$2a$10$abcdefghijklmnopqrstuu"},
+		{"missing key", strings.Join(parts[:5], "$")},
+		{"wrong version", strings.Replace(valid, "v=19", "v=16", 1)},
+		{"bad params", strings.Replace(valid, "m=1024,t=1,p=1", "m=x,t=1,p=1", 1)},
+		{"zero memory", strings.Replace(valid, "m=1024", "m=0", 1)},
+		{"bad salt", strings.Replace(valid, parts[4], "!!!", 1)},
+		{"bad key", strings.Replace(valid, parts[5], "!!!", 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, err := parseArgon2Hash(tt.hash); err != ErrInvalidPasswordHash {
+				t.Errorf("parseArgon2Hash(%q) err = %v, want ErrInvalidPasswordHash", tt.hash, err)
+			}
+		})
+	}
+}
+
+func TestCheckPasswordHash(t *testing.T) {
+	argonHash, err := HashPassword("benar-sekali-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("benar-sekali-1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		hash string
+		pw   string
+		want bool
+	}{
+		{"argon2id correct", argonHash, "benar-sekali-1", true},
+		{"argon2id wrong", argonHash, "salah-sekali-1", false},
+		{"bcrypt correct", string(bcryptHash), "benar-sekali-1", true},
+		{"bcrypt wrong", string(bcryptHash), "salah-sekali-1", false},
+		{"corrupt argon2id", "$argon2id$v=19$m=0,t=0,p=0$$", "benar-sekali-1", false},
+		{"empty hash", "", "benar-sekali-1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckPasswordHash(tt.hash, tt.pw); got != tt.want {
+				t.Errorf("CheckPasswordHash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	current, err := HashPassword("pw-sekarang-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("pw-lama-1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weaker := currentArgon2
+	weaker.Time = 1
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{"current argon2id", current, false},
+		{"bcrypt", string(bcryptHash), true},
+		{"argon2id old params", argon2Hash("pw-lama-1", weaker), true},
+		{"argon2id shorter salt", argon2Hash("pw-lama-1", argon2Params{Memory: currentArgon2.Memory, Time: currentArgon2.Time, Threads: currentArgon2.Threads, SaltLen: 8, KeyLen: currentArgon2.KeyLen}), true},
+		{"corrupt argon2id", "$argon2id$v=19$broken", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PasswordNeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("PasswordNeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Password policy, panjang bisa diatur lewat env PASSWORD_MIN_LENGTH (default 8)
+// dan PASSWORD_MAX_LENGTH (default 128). Password yang ada di common_passwords.txt (di-embed ke binary) selalu ditolak.
+const (
+	defaultPasswordMinLength = 8
+	defaultPasswordMaxLength = 128
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var (
+	commonPasswordsOnce sync.Once
+	commonPasswords     map[string]struct{}
+)
+
+// PasswordPolicyError pesannya aman untuk langsung ditampilkan ke user
+type PasswordPolicyError struct {
+	Reason string
+}
+
+func (e *PasswordPolicyError) Error() string { return e.Reason }
+
+// ValidatePassword cek password baru terhadap policy. personal berisi data user
+// (nomor HP, nama) yang tidak boleh dipakai sebagai password.
+func ValidatePassword(pw string, personal ...string) error {
+	minLen := envInt("PASSWORD_MIN_LENGTH", defaultPasswordMinLength)
+	maxLen := envInt("PASSWORD_MAX_LENGTH", defaultPasswordMaxLength)
+
+	length := len([]rune(pw))
+	if length < minLen {
+		return &PasswordPolicyError{fmt.Sprintf("Password minimal %d karakter", minLen)}
+	}
+	if length > maxLen {
+		return &PasswordPolicyError{fmt.Sprintf("Password maksimal %d karakter", maxLen)}
+	}
+
+	lower := strings.ToLower(pw)
+	if isCommonPassword(lower) {
+		return &PasswordPolicyError{"Password terlalu umum, gunakan password lain"}
+	}
+
+	for _, p := range personal {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p != "" && (lower == p || lower == strings.TrimPrefix(p, "+")) {
+			return &PasswordPolicyError{"Password tidak boleh sama dengan nomor HP atau nama"}
+		}
+	}
+	return nil
+}
+
+func isCommonPassword(pw string) bool {
+	commonPasswordsOnce.Do(func() {
+		commonPasswords = make(map[string]struct{})
+		for _, line := range strings.Split(commonPasswordsFile, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			commonPasswords[strings.ToLower(line)] = struct{}{}
+		}
+	})
+	_, ok := commonPasswords[pw]
+	return ok
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		pw       string
+		personal []string
+		reason   string // kosong = lolos
+	}{
+		{"ok", "kaca-tempered-88", nil, ""},
+		{"ok multibyte", "kacaßßßß", nil, ""},
+		{"too short", "abc123", nil, "Password minimal 8 karakter"},
+		{"too long", strings.Repeat("a", 129), nil, "Password maksimal 128 karakter"},
+		{"common", "password123", nil, "Password terlalu umum, gunakan password lain"},
+		{"common case-insensitive", "PassWord123", nil, "Password terlalu umum, gunakan password lain"},
+		{"same as phone", "+6281234567890", []string{"+6281234567890"}, "Password tidak boleh sama dengan nomor HP atau nama"},
+		{"phone without plus", "6281234567890", []string{"+6281234567890"}, "Password tidak boleh sama dengan nomor HP atau nama"},
+		{"same as name", "BudiSantoso", []string{"+6281234567890", " budisantoso "}, "Password tidak boleh sama dengan nomor HP atau nama"},
+		{"empty personal ignored", "kaca-tempered-88", []string{"", "  "}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePassword(tt.pw, tt.personal...)
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("ValidatePassword(%q) = %v, want nil", tt.pw, err)
+				}
+				return
+			}
+			var policyErr *PasswordPolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("ValidatePassword(%q) = %v, want *PasswordPolicyError", tt.pw, err)
+			}
+			if policyErr.Reason != tt.reason {
+				t.Errorf("reason = %q, want %q", policyErr.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestValidatePasswordEnvLength(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("PASSWORD_MAX_LENGTH", "16")
+
+	tests := []struct {
+		pw    string
+		valid bool
+	}{
+		{"kaca-temper", false},       // 11
+		{"kaca-tempered", true},      // 13
+		{"kaca-tempered-999", false}, // 17
+	}
+	for _, tt := range tests {
+		if err := ValidatePassword(tt.pw); (err == nil) != tt.valid {
+			t.Errorf("ValidatePassword(%q) = %v, want valid=%v", tt.pw, err, tt.valid)
+		}
+	}
+}
+
+func TestValidatePasswordInvalidEnvFallsBack(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "bukan-angka")
+	if err := ValidatePassword("abcdefg"); err == nil {
+		t.Fatal("7 characters accepted with invalid PASSWORD_MIN_LENGTH, want default 8")
+	}
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+// Semua nomor HP disimpan dalam format E.164 (+628xxxxxxxxx) supaya
+// 0812..., 62812..., +62 812-... dianggap nomor yang sama. Angka 0 setelah kode negara
+// (62 0812..., +62 0812...) dianggap salah ketik dan dibuang.
+
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+const (
+	phoneCountryCode = "62"
+	// panjang nomor Indonesia tanpa kode negara (NSN)
+	phoneMinNationalDigits = 8
+	phoneMaxNationalDigits = 12
+	// batas E.164 untuk nomor luar negeri
+	phoneMinIntlDigits = 8
+	phoneMaxIntlDigits = 15
+)
+
+// NormalizePhone mengubah nomor HP ke format E.164. Nomor tanpa kode negara
+// dianggap nomor Indonesia. Spasi, titik, strip dan kurung diabaikan.
+func NormalizePhone(raw string) (string, error) {
+	s := strings.TrimSpace(raw)
+	international := strings.HasPrefix(s, "+")
+	if international {
+		s = s[1:]
+	}
+
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+			// pemisah yang biasa diketik user
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+	d := digits.String()
+
+	// prefix internasional 00 (mis. 0062812...)
+	if !international && strings.HasPrefix(d, "00") {
+		d = d[2:]
+		international = true
+	}
+
+	switch {
+	case international && strings.HasPrefix(d, phoneCountryCode):
+		d = strings.TrimPrefix(d[len(phoneCountryCode):], "0") // +62 0812... salah ketik umum
+	case international:
+		if len(d) < phoneMinIntlDigits || len(d) > phoneMaxIntlDigits || d[0] == '0' {
+			return "", ErrInvalidPhone
+		}
+		return "+" + d, nil
+	case strings.HasPrefix(d, "0"):
+		d = d[1:]
+	case strings.HasPrefix(d, phoneCountryCode):
+		d = strings.TrimPrefix(d[len(phoneCountryCode):], "0") // sama seperti +62 0812...
+	}
+
+	if len(d) < phoneMinNationalDigits || len(d) > phoneMaxNationalDigits || d[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	return "+" + phoneCountryCode + d, nil
+}
+
+// NormalizePhoneOrRaw dipakai di lookup (login, OTP, lupa password): nomor yang
+// tidak valid tetap diteruskan apa adanya supaya respons-nya sama dengan nomor
+// yang tidak terdaftar.
+func NormalizePhoneOrRaw(raw string) string {
+	if phone, err := NormalizePhone(raw); err == nil {
+		return phone
+	}
+	return strings.TrimSpace(raw)
+}
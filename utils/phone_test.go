@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string // kosong = ErrInvalidPhone
+	}{
+		{"local", "081234567890", "+6281234567890"},
+		{"country code", "6281234567890", "+6281234567890"},
+		{"country code with trunk zero", "62081234567890", "+6281234567890"},
+		{"e164", "+6281234567890", "+6281234567890"},
+		{"e164 with separators", "+62 812-3456-7890", "+6281234567890"},
+		{"e164 with trunk zero", "+62 0812 3456 7890", "+6281234567890"},
+		{"00 prefix", "0062 812 3456 7890", "+6281234567890"},
+		{"00 prefix with trunk zero", "00620812 3456 7890", "+6281234567890"},
+		{"brackets and dots", "(0812) 3456.7890", "+6281234567890"},
+		{"national without prefix", "812 3456 7890", "+6281234567890"},
+		{"foreign", "+1 415 555 2671", "+14155552671"},
+		{"foreign starting with zero", "+0123456789", ""},
+		{"too short", "0812345", ""},
+		{"too long", "0812345678901234", ""},
+		{"only country code", "620", ""},
+		{"letters", "0812abc4567", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhone(tt.raw)
+			if tt.want == "" {
+				if !errors.Is(err, ErrInvalidPhone) {
+					t.Fatalf("NormalizePhone(%q) = %q, %v, want ErrInvalidPhone", tt.raw, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("NormalizePhone(%q) = %q, %v, want %q", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePhoneOrRaw(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{"0812-3456-7890", "+6281234567890"},
+		{"620812 3456 7890", "+6281234567890"},
+		{" not-a-phone ", "not-a-phone"},
+	}
+	for _, tt := range tests {
+		if got := NormalizePhoneOrRaw(tt.raw); got != tt.want {
+			t.Errorf("NormalizePhoneOrRaw(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
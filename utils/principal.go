@@ -0,0 +1,40 @@
+package utils
+
+import "github.com/gin-gonic/gin"
+
+const (
+	PrincipalUser   = "user"
+	PrincipalAPIKey = "api_key"
+)
+
+// Principal adalah identitas pemanggil yang sudah diautentikasi, baik lewat JWT user
+// maupun API key. Disimpan AuthMiddleware di context dengan key "principal".
+type Principal struct {
+	Type   string   `json:"type"`
+	ID     string   `json:"id"` // user_id atau id API key
+	Role   string   `json:"role,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// Can cek permission: user lewat role-nya, API key lewat scope-nya
+func (p *Principal) Can(permission string) bool {
+	if p == nil {
+		return false
+	}
+	if p.Type == PrincipalAPIKey {
+		for _, s := range p.Scopes {
+			if s == permission {
+				return true
+			}
+		}
+		return false
+	}
+	return HasPermission(p.Role, permission)
+}
+
+// CurrentPrincipal mengambil principal dari context (nil kalau belum login)
+func CurrentPrincipal(c *gin.Context) *Principal {
+	value, _ := c.Get("principal")
+	p, _ := value.(*Principal)
+	return p
+}
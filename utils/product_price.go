@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Satuan harga produk
+const (
+	PriceUnitPiece       = "unit"  // per batang / lembar / pcs
+	PriceUnitMetre       = "metre" // per meter lari
+	PriceUnitSquareMetre = "m2"    // per meter persegi
+)
+
+var priceUnits = map[string]bool{
+	PriceUnitPiece:       true,
+	PriceUnitMetre:       true,
+	PriceUnitSquareMetre: true,
+}
+
+// ValidPriceUnit cek satuan harga yang didukung
+func ValidPriceUnit(unit string) bool {
+	return priceUnits[unit]
+}
+
+// ApplyProductPrice menerapkan 1 baris ProductPrice ke produknya: harga produk
+// diganti, harga lama disalin ke Previous*, lalu AppliedAt diisi.
+// Harus dipanggil di dalam transaksi.
+func ApplyProductPrice(tx *gorm.DB, price *models.ProductPrice) error {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", price.ProductID).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	price.PreviousAmount = product.Price
+	price.PreviousUnit = product.PriceUnit
+	price.PreviousMinOrderQty = product.MinOrderQty
+	price.AppliedAt = &now
+
+	if err := tx.Model(&product).Updates(map[string]interface{}{
+		"price":         price.Amount,
+		"price_unit":    price.Unit,
+		"min_order_qty": price.MinOrderQty,
+	}).Error; err != nil {
+		return err
+	}
+	if price.ID == uuid.Nil {
+		return tx.Create(price).Error
+	}
+	return tx.Save(price).Error
+}
+
+// ApplyDueProductPrices menerapkan semua harga terjadwal yang EffectiveAt-nya sudah lewat,
+// urut dari yang paling lama. productID uuid.Nil = semua produk.
+func ApplyDueProductPrices(productID uuid.UUID) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("applied_at IS NULL AND effective_at <= ?", time.Now())
+		if productID != uuid.Nil {
+			query = query.Where("product_id = ?", productID)
+		}
+
+		var due []models.ProductPrice
+		if err := query.Order("effective_at ASC, created_at ASC").Find(&due).Error; err != nil {
+			return err
+		}
+		for i := range due {
+			if err := ApplyProductPrice(tx, &due[i]); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					// produk sudah dihapus, jadwalnya dibuang
+					if err := tx.Delete(&due[i]).Error; err != nil {
+						return err
+					}
+					continue
+				}
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RunPriceScheduler menerapkan harga terjadwal secara berkala. Dijalankan sebagai goroutine dari main.
+func RunPriceScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := ApplyDueProductPrices(uuid.Nil); err != nil {
+			log.Println("❌ Failed to apply scheduled prices:", err)
+		}
+		<-ticker.C
+	}
+}
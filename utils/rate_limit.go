@@ -0,0 +1,9 @@
+package utils
+
+import "time"
+
+// AllowRequest rate limit sederhana per key (contoh "quote:<ip>") memakai store login guard:
+// true selama jumlah request dalam window belum melewati limit.
+func AllowRequest(key string, limit int64, window time.Duration) bool {
+	return guardStore.incr("rate:"+key, window) <= limit
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+)
+
+// Role bawaan, di-seed ke tabel roles saat startup
+const (
+	RoleAdmin     = "admin"
+	RoleSales     = "sales"
+	RoleInstaller = "installer"
+	RoleWorkshop  = "workshop"
+	RoleCustomer  = "customer"
+)
+
+// Permission dipakai oleh middleware RequirePermission
+const (
+	PermCatalogRead  = "catalog:read"
+	PermCatalogWrite = "catalog:write"
+	PermOrdersRead   = "orders:read"
+	PermOrdersWrite  = "orders:write"
+	PermUsersRead    = "users:read"
+	PermUsersWrite   = "users:write"
+	PermInfoWrite    = "info:write"
+	PermStaffManage  = "staff:manage"
+
+	PermUsersImpersonate = "users:impersonate"
+	PermUsersPrivacy     = "users:privacy" // export & hapus data pribadi (UU PDP)
+	PermUsersCRM         = "users:crm"     // tag & catatan internal customer
+)
+
+// DefaultRolePermissions adalah matriks awal yang di-seed ke database.
+// Setelah itu sumber kebenarannya tabel roles / role_permissions.
+var DefaultRolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermCatalogRead,
+		PermCatalogWrite,
+		PermOrdersRead,
+		PermOrdersWrite,
+		PermUsersRead,
+		PermUsersWrite,
+		PermInfoWrite,
+		PermStaffManage,
+		PermUsersImpersonate,
+		PermUsersPrivacy,
+		PermUsersCRM,
+	},
+	RoleSales: {
+		PermCatalogRead,
+		PermOrdersRead,
+		PermOrdersWrite,
+		PermUsersRead,
+		PermUsersCRM,
+	},
+	RoleInstaller: {
+		PermCatalogRead,
+		PermOrdersRead,
+		PermOrdersWrite,
+	},
+	RoleWorkshop: {
+		PermCatalogRead,
+		PermOrdersRead,
+		PermOrdersWrite,
+	},
+	RoleCustomer: {
+		PermCatalogRead,
+	},
+}
+
+// cache permission per role dari database, di-reload berkala supaya
+// perubahan dari instance lain ikut terbaca
+const rolePermissionsTTL = time.Minute
+
+var (
+	rolePermsMu       sync.RWMutex
+	rolePermissions   map[string]map[string]bool
+	rolePermsLoadedAt time.Time
+)
+
+// NormalizeRole menyamakan penulisan role dari DB / token.
+// Role kosong atau "user" (format lama) dianggap customer.
+func NormalizeRole(role string) string {
+	role = strings.ToLower(strings.TrimSpace(role))
+	if role == "" || role == "user" {
+		return RoleCustomer
+	}
+	return role
+}
+
+// LoadRolePermissions memuat ulang matriks permission dari tabel roles
+func LoadRolePermissions() error {
+	var roles []models.Role
+	if err := config.DB.Preload("Permissions").Find(&roles).Error; err != nil {
+		return err
+	}
+
+	perms := make(map[string]map[string]bool, len(roles))
+	for _, r := range roles {
+		perms[r.Name] = make(map[string]bool, len(r.Permissions))
+		for _, p := range r.Permissions {
+			perms[r.Name][p.Name] = true
+		}
+	}
+
+	rolePermsMu.Lock()
+	rolePermissions = perms
+	rolePermsLoadedAt = time.Now()
+	rolePermsMu.Unlock()
+	return nil
+}
+
+// HasPermission cek apakah role punya permission tertentu
+func HasPermission(role, permission string) bool {
+	role = NormalizeRole(role)
+
+	rolePermsMu.RLock()
+	perms, stale := rolePermissions, time.Since(rolePermsLoadedAt) > rolePermissionsTTL
+	rolePermsMu.RUnlock()
+
+	if (perms == nil || stale) && config.DB != nil {
+		if err := LoadRolePermissions(); err != nil {
+			log.Println("⚠️ Failed to load role permissions:", err)
+		}
+		rolePermsMu.RLock()
+		perms = rolePermissions
+		rolePermsMu.RUnlock()
+	}
+
+	// belum ada data di DB → pakai matriks bawaan
+	if len(perms) == 0 {
+		for _, p := range DefaultRolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+		return false
+	}
+
+	return perms[role][permission]
+}
+
+// DefaultRoles menyusun role bawaan untuk config.SeedRoles
+func DefaultRoles() []models.Role {
+	descriptions := map[string]string{
+		RoleAdmin:     "Akses penuh, termasuk kelola staff dan role",
+		RoleSales:     "Kelola pesanan dan data customer",
+		RoleInstaller: "Lihat dan update status pesanan di lapangan",
+		RoleWorkshop:  "Lihat dan update status produksi pesanan",
+		RoleCustomer:  "Customer toko",
+	}
+
+	roles := []models.Role{}
+	for _, name := range []string{RoleAdmin, RoleSales, RoleInstaller, RoleWorkshop, RoleCustomer} {
+		role := models.Role{Name: name, Description: descriptions[name], IsStaff: name != RoleCustomer}
+		for _, p := range DefaultRolePermissions[name] {
+			role.Permissions = append(role.Permissions, models.Permission{Name: p})
+		}
+		roles = append(roles, role)
+	}
+	return roles
+}
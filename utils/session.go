@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+)
+
+// last_seen_at cukup ditulis maksimal 1x per menit per session
+const sessionTouchInterval = time.Minute
+
+var (
+	sessionTouchMu   sync.Mutex
+	sessionTouchedAt = map[string]time.Time{}
+)
+
+func redisKeySessionSeen(sid string) string { return "session_seen:" + sid }
+
+// CreateSession mencatat login baru. sessionID = family refresh token.
+func CreateSession(userID, sessionID, device, ip, userAgent string) error {
+	now := time.Now()
+	session := models.Session{
+		ID:         ParseUUID(sessionID),
+		UserID:     ParseUUID(userID),
+		Device:     device,
+		IP:         ip,
+		UserAgent:  userAgent,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(RefreshTokenTTL),
+	}
+	return config.DB.Create(&session).Error
+}
+
+// TouchSession memperbarui last_seen_at, ip dan masa berlaku session.
+// Dipanggil di setiap request terautentikasi, jadi dibatasi 1x per menit.
+func TouchSession(sessionID, ip string) {
+	if sessionID == "" || !shouldTouchSession(sessionID) {
+		return
+	}
+
+	now := time.Now()
+	err := config.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", ParseUUID(sessionID)).
+		UpdateColumns(map[string]interface{}{
+			"last_seen_at": now,
+			"ip":           ip,
+			"expires_at":   now.Add(RefreshTokenTTL),
+		}).Error
+	if err != nil {
+		log.Println("⚠️ Failed to update session last_seen_at:", err)
+	}
+}
+
+func shouldTouchSession(sessionID string) bool {
+	if config.RedisClient != nil {
+		ok, err := config.RedisClient.SetNX(context.Background(), redisKeySessionSeen(sessionID), 1, sessionTouchInterval).Result()
+		if err == nil {
+			return ok
+		}
+	}
+
+	sessionTouchMu.Lock()
+	defer sessionTouchMu.Unlock()
+	if t, ok := sessionTouchedAt[sessionID]; ok && time.Since(t) < sessionTouchInterval {
+		return false
+	}
+	sessionTouchedAt[sessionID] = time.Now()
+	return true
+}
+
+// sweepSessionTouches menghapus catatan touch yang sudah lewat sessionTouchInterval
+func sweepSessionTouches() {
+	sessionTouchMu.Lock()
+	defer sessionTouchMu.Unlock()
+	for sid, t := range sessionTouchedAt {
+		if time.Since(t) >= sessionTouchInterval {
+			delete(sessionTouchedAt, sid)
+		}
+	}
+}
+
+// DeviceName menebak nama perangkat dari User-Agent (kalau client tidak kirim X-Device-Name)
+func DeviceName(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	platform := ""
+	switch {
+	case strings.Contains(ua, "iphone"):
+		platform = "iPhone"
+	case strings.Contains(ua, "ipad"):
+		platform = "iPad"
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os"):
+		platform = "Mac"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	app := ""
+	switch {
+	case strings.Contains(ua, "dart"), strings.Contains(ua, "okhttp"):
+		app = "App"
+	case strings.Contains(ua, "edg/"):
+		app = "Edge"
+	case strings.Contains(ua, "chrome"):
+		app = "Chrome"
+	case strings.Contains(ua, "firefox"):
+		app = "Firefox"
+	case strings.Contains(ua, "safari"):
+		app = "Safari"
+	}
+
+	switch {
+	case platform != "" && app != "":
+		return app + " on " + platform
+	case platform != "":
+		return platform
+	case app != "":
+		return app
+	default:
+		return "Unknown device"
+	}
+}
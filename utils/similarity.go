@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeText huruf kecil, tanpa tanda baca, spasi dirapikan.
+// Dipakai untuk membandingkan nama / alamat yang diketik bebas.
+func NormalizeText(s string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if !space {
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// EscapeLike meng-escape \, % dan _ supaya input user dicocokkan apa adanya di LIKE / ILIKE
+// (escape default PostgreSQL adalah backslash)
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// TextSimilarity skor 0..1 (koefisien Dice atas bigram huruf) dari dua teks
+// yang sudah / belum dinormalisasi. Tahan terhadap salah ketik kecil dan urutan kata.
+func TextSimilarity(a, b string) float64 {
+	a, b = NormalizeText(a), NormalizeText(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	bigramsA := textBigrams(a)
+	bigramsB := textBigrams(b)
+	total := 0
+	for _, n := range bigramsA {
+		total += n
+	}
+	for _, n := range bigramsB {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	shared := 0
+	for bg, n := range bigramsA {
+		if m := bigramsB[bg]; m > 0 {
+			shared += min(n, m)
+		}
+	}
+	return 2 * float64(shared) / float64(total)
+}
+
+func textBigrams(s string) map[string]int {
+	bigrams := map[string]int{}
+	for _, word := range strings.Fields(s) {
+		runes := []rune(word)
+		if len(runes) == 1 {
+			bigrams[word]++
+			continue
+		}
+		for i := 0; i < len(runes)-1; i++ {
+			bigrams[string(runes[i:i+2])]++
+		}
+	}
+	return bigrams
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Auth untuk koneksi SSE / WebSocket. Browser tidak bisa set header di EventSource
+// dan WebSocket, jadi selain Bearer token diterima juga cookie access_token atau
+// connect ticket sekali pakai dari POST /api/events/ticket.
+const (
+	ConnectTicketTTL  = 30 * time.Second
+	AccessTokenCookie = "access_token"
+)
+
+// sumber kredensial koneksi stream
+const (
+	StreamAuthHeader = "header"
+	StreamAuthCookie = "cookie"
+	StreamAuthTicket = "ticket"
+)
+
+var ErrStreamUnauthorized = errors.New("stream unauthorized")
+
+// StreamAuthCheckInterval interval cek token dicabut selama koneksi SSE / WebSocket terbuka
+const StreamAuthCheckInterval = 30 * time.Second
+
+// StreamAuth adalah identitas koneksi yang sudah divalidasi (dari token, bukan dari query).
+// Koneksi ditutup saat ExpiresAt lewat atau StillValid() mengembalikan false.
+type StreamAuth struct {
+	UserID     string
+	Role       string
+	ExpiresAt  time.Time
+	StillValid func() bool
+}
+
+// NewStreamAuth membuat StreamAuth dari claims hasil AuthenticateStream
+func NewStreamAuth(claims *Claims) StreamAuth {
+	return StreamAuth{
+		UserID:     claims.UserID,
+		Role:       NormalizeRole(claims.Role),
+		ExpiresAt:  claims.ExpiresAt.Time,
+		StillValid: func() bool { return StreamStillValid(claims) },
+	}
+}
+
+func redisKeyConnectTicket(hash string) string { return "connect_ticket:" + hash }
+
+// IssueConnectTicket membuat ticket singkat yang membawa claims access token saat ini
+func IssueConnectTicket(claims *Claims) (string, error) {
+	ticket, err := RandomToken(24)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	ephemeral.put(redisKeyConnectTicket(HashToken(ticket)), data, ConnectTicketTTL)
+	return ticket, nil
+}
+
+// redeemConnectTicket menukar ticket dengan claims-nya (ticket langsung hangus)
+func redeemConnectTicket(ticket string) (*Claims, error) {
+	data, ok := ephemeral.get(redisKeyConnectTicket(HashToken(ticket)), true)
+	if !ok {
+		return nil, ErrStreamUnauthorized
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return nil, ErrStreamUnauthorized
+	}
+	if !StreamStillValid(&claims) {
+		return nil, ErrStreamUnauthorized
+	}
+	return &claims, nil
+}
+
+// AuthenticateStream mengambil claims dari header Authorization, cookie access_token,
+// atau query ?ticket=. user_id & role tidak pernah diambil dari query.
+func AuthenticateStream(c *gin.Context) (*Claims, string, error) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		claims, err := ValidateJWT(strings.TrimPrefix(header, "Bearer "))
+		return claims, StreamAuthHeader, err
+	}
+	if ticket := c.Query("ticket"); ticket != "" {
+		claims, err := redeemConnectTicket(ticket)
+		return claims, StreamAuthTicket, err
+	}
+	if cookie, err := c.Cookie(AccessTokenCookie); err == nil && cookie != "" {
+		claims, err := ValidateJWT(cookie)
+		return claims, StreamAuthCookie, err
+	}
+	return nil, "", ErrStreamUnauthorized
+}
+
+// StreamStillValid dipakai untuk memutus koneksi yang token-nya sudah expired / dicabut
+func StreamStillValid(claims *Claims) bool {
+	if claims.ExpiresAt == nil || claims.ExpiresAt.Time.Before(time.Now()) {
+		return false
+	}
+	return !IsAccessTokenRevoked(claims.ID) && !IsRefreshFamilyRevoked(claims.SessionID)
+}
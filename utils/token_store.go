@@ -0,0 +1,369 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ary/go-api/config"
+	"github.com/ary/go-api/models"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Refresh token disimpan di Redis (config.RedisClient).
+// Kalau Redis tidak tersedia / error, otomatis pakai tabel Postgres.
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// revocationNegativeTTL lama cache "belum dicabut" di Redis, supaya tiap request
+// terautentikasi tidak selalu query Postgres
+const revocationNegativeTTL = 30 * time.Second
+
+var (
+	ErrTokenRevoked        = errors.New("token revoked")
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// RefreshTokenRecord adalah data refresh token yang disimpan server
+type RefreshTokenRecord struct {
+	TokenHash string    `json:"token_hash"`
+	UserID    string    `json:"user_id"`
+	FamilyID  string    `json:"family_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func redisKeyRefresh(hash string) string      { return "refresh:" + hash }
+func redisKeyRefreshUsed(hash string) string  { return "refresh_used:" + hash }
+func redisKeyFamilyRevoked(fid string) string { return "refresh_family_revoked:" + fid }
+func redisKeyRevokedJTI(jti string) string    { return "revoked_jti:" + jti }
+func redisKeyUserFamilies(uid string) string  { return "refresh_user_families:" + uid }
+
+// HashToken menghasilkan hash sha256 (hex) dari token opaque
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RandomToken membuat string acak url-safe dari n byte
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// IssueRefreshToken membuat refresh token baru untuk family (sesi login) tertentu
+func IssueRefreshToken(userID, familyID string) (string, error) {
+	token, err := RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	rec := RefreshTokenRecord{
+		TokenHash: HashToken(token),
+		UserID:    userID,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}
+
+	if config.RedisClient != nil {
+		ctx := context.Background()
+		data, _ := json.Marshal(rec)
+		err := config.RedisClient.Set(ctx, redisKeyRefresh(rec.TokenHash), data, RefreshTokenTTL).Err()
+		if err == nil {
+			// index family per user, dipakai RevokeUserTokens
+			config.RedisClient.SAdd(ctx, redisKeyUserFamilies(userID), familyID)
+			config.RedisClient.Expire(ctx, redisKeyUserFamilies(userID), RefreshTokenTTL)
+			return token, nil
+		}
+		log.Println("⚠️ Redis refresh token write failed, fallback to Postgres:", err)
+	}
+
+	row := models.RefreshToken{
+		TokenHash: rec.TokenHash,
+		UserID:    ParseUUID(userID),
+		FamilyID:  ParseUUID(familyID),
+		ExpiresAt: rec.ExpiresAt,
+	}
+	if err := config.DB.Create(&row).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RotateRefreshToken menukar refresh token lama dengan yang baru (1x pakai).
+// Kalau token lama sudah pernah dipakai, seluruh family dicabut.
+func RotateRefreshToken(token string) (*RefreshTokenRecord, string, error) {
+	hash := HashToken(token)
+
+	rec, fromRedis, err := findRefreshToken(hash)
+	if err != nil {
+		return nil, "", err
+	}
+	if rec == nil || rec.ExpiresAt.Before(time.Now()) {
+		return nil, "", ErrRefreshTokenInvalid
+	}
+	if IsRefreshFamilyRevoked(rec.FamilyID) {
+		return nil, "", ErrRefreshTokenInvalid
+	}
+
+	firstUse, err := markRefreshTokenUsed(hash, rec, fromRedis)
+	if err != nil {
+		return nil, "", err
+	}
+	if !firstUse {
+		// token lama dipakai ulang → kemungkinan dicuri
+		log.Printf("🚨 Refresh token reuse detected: user=%s family=%s", rec.UserID, rec.FamilyID)
+		if err := RevokeRefreshFamily(rec.FamilyID); err != nil {
+			log.Println("⚠️ Failed to revoke refresh token family:", err)
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	newToken, err := IssueRefreshToken(rec.UserID, rec.FamilyID)
+	if err != nil {
+		return nil, "", err
+	}
+	return rec, newToken, nil
+}
+
+func findRefreshToken(hash string) (*RefreshTokenRecord, bool, error) {
+	if config.RedisClient != nil {
+		data, err := config.RedisClient.Get(context.Background(), redisKeyRefresh(hash)).Bytes()
+		if err == nil {
+			var rec RefreshTokenRecord
+			if err := json.Unmarshal(data, &rec); err != nil {
+				return nil, false, err
+			}
+			return &rec, true, nil
+		}
+		if !errors.Is(err, redis.Nil) {
+			log.Println("⚠️ Redis refresh token read failed, fallback to Postgres:", err)
+		}
+	}
+
+	var row models.RefreshToken
+	err := config.DB.Where("token_hash = ?", hash).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if row.RevokedAt != nil {
+		return nil, false, nil
+	}
+
+	return &RefreshTokenRecord{
+		TokenHash: row.TokenHash,
+		UserID:    row.UserID.String(),
+		FamilyID:  row.FamilyID.String(),
+		ExpiresAt: row.ExpiresAt,
+	}, false, nil
+}
+
+// markRefreshTokenUsed return true kalau ini pemakaian pertama
+func markRefreshTokenUsed(hash string, rec *RefreshTokenRecord, fromRedis bool) (bool, error) {
+	if fromRedis {
+		ttl := time.Until(rec.ExpiresAt)
+		return config.RedisClient.SetNX(context.Background(), redisKeyRefreshUsed(hash), 1, ttl).Result()
+	}
+
+	res := config.DB.Model(&models.RefreshToken{}).
+		Where("token_hash = ? AND used_at IS NULL", hash).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// RevokeRefreshFamily mencabut semua refresh token dalam 1 family (sesi login).
+// Access token yang membawa sid family ini juga ikut ditolak.
+func RevokeRefreshFamily(familyID string) error {
+	if config.RedisClient != nil {
+		if err := config.RedisClient.Set(context.Background(), redisKeyFamilyRevoked(familyID), 1, RefreshTokenTTL).Err(); err != nil {
+			log.Println("⚠️ Redis family revoke failed:", err)
+		}
+	}
+
+	now := time.Now()
+	if err := config.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", ParseUUID(familyID)).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	return config.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", ParseUUID(familyID)).
+		Update("revoked_at", now).Error
+}
+
+// IsRefreshFamilyRevoked cek apakah family (sesi login) sudah dicabut.
+// Redis hanya cache: kalau di Redis tidak ada (misal revoke waktu Redis mati / gagal set),
+// Postgres tetap dicek dan hasilnya disalin lagi ke Redis.
+func IsRefreshFamilyRevoked(familyID string) bool {
+	if familyID == "" {
+		return false
+	}
+
+	key := redisKeyFamilyRevoked(familyID)
+	if revoked, ok := cachedRevocation(key); ok {
+		return revoked
+	}
+
+	var count int64
+	config.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NOT NULL", ParseUUID(familyID)).
+		Count(&count)
+	if count == 0 {
+		config.DB.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NOT NULL", ParseUUID(familyID)).
+			Count(&count)
+	}
+
+	if count > 0 {
+		cacheRevocation(key, true, RefreshTokenTTL)
+		return true
+	}
+	cacheRevocation(key, false, revocationNegativeTTL)
+	return false
+}
+
+// RevokeUserTokens mencabut semua sesi login user (semua family refresh token),
+// kecuali family exceptFamilyID (misal sesi yang sedang dipakai). Kosongkan untuk cabut semua.
+func RevokeUserTokens(userID, exceptFamilyID string) error {
+	families := map[string]struct{}{}
+
+	if config.RedisClient != nil {
+		members, err := config.RedisClient.SMembers(context.Background(), redisKeyUserFamilies(userID)).Result()
+		if err != nil {
+			log.Println("⚠️ Redis user families read failed:", err)
+		}
+		for _, fid := range members {
+			families[fid] = struct{}{}
+		}
+	}
+
+	var dbFamilies []uuid.UUID
+	if err := config.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", ParseUUID(userID)).
+		Distinct().Pluck("family_id", &dbFamilies).Error; err != nil {
+		return err
+	}
+	for _, fid := range dbFamilies {
+		families[fid.String()] = struct{}{}
+	}
+
+	var sessions []uuid.UUID
+	if err := config.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", ParseUUID(userID)).
+		Pluck("id", &sessions).Error; err != nil {
+		return err
+	}
+	for _, sid := range sessions {
+		families[sid.String()] = struct{}{}
+	}
+
+	for fid := range families {
+		if fid == exceptFamilyID {
+			continue
+		}
+		if err := RevokeRefreshFamily(fid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeAccessToken memasukkan jti access token ke revocation list sampai token expired
+func RevokeAccessToken(jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+
+	if config.RedisClient != nil {
+		if err := config.RedisClient.Set(context.Background(), redisKeyRevokedJTI(jti), 1, ttl).Err(); err != nil {
+			log.Println("⚠️ Redis revoke access token failed:", err)
+		}
+	}
+
+	// bersihkan yang sudah expired sekalian
+	config.DB.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{})
+
+	return config.DB.Save(&models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+// IsAccessTokenRevoked cek jti di revocation list.
+// Sama seperti family: Redis kosong belum tentu tidak dicabut, jadi Postgres tetap dicek.
+func IsAccessTokenRevoked(jti string) bool {
+	if jti == "" {
+		return false
+	}
+
+	key := redisKeyRevokedJTI(jti)
+	if revoked, ok := cachedRevocation(key); ok {
+		return revoked
+	}
+
+	var rec models.RevokedToken
+	err := config.DB.Where("jti = ? AND expires_at > ?", jti, time.Now()).Take(&rec).Error
+	if err == nil {
+		cacheRevocation(key, true, time.Until(rec.ExpiresAt))
+		return true
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Println("⚠️ Postgres revocation check failed:", err)
+		return false
+	}
+	cacheRevocation(key, false, revocationNegativeTTL)
+	return false
+}
+
+// cachedRevocation baca status revoke dari Redis: "1" dicabut, "0" belum dicabut.
+// ok=false kalau key tidak ada / Redis error, caller harus cek Postgres.
+func cachedRevocation(key string) (revoked, ok bool) {
+	if config.RedisClient == nil {
+		return false, false
+	}
+	val, err := config.RedisClient.Get(context.Background(), key).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Println("⚠️ Redis revocation check failed, fallback to Postgres:", err)
+		}
+		return false, false
+	}
+	return val != "0", true
+}
+
+// cacheRevocation simpan hasil cek Postgres ke Redis. Hasil negatif ("0") hanya disimpan
+// sebentar dan pakai SETNX, supaya tidak menimpa "1" dari revoke yang terjadi bersamaan;
+// RevokeRefreshFamily / RevokeAccessToken selalu menimpa "0" dengan "1".
+func cacheRevocation(key string, revoked bool, ttl time.Duration) {
+	if config.RedisClient == nil || ttl <= 0 {
+		return
+	}
+	var err error
+	if revoked {
+		err = config.RedisClient.Set(context.Background(), key, "1", ttl).Err()
+	} else {
+		err = config.RedisClient.SetNX(context.Background(), key, "0", ttl).Err()
+	}
+	if err != nil {
+		log.Println("⚠️ Redis revocation cache failed:", err)
+	}
+}
+
+// NewFamilyID membuat id family baru untuk setiap login
+func NewFamilyID() string {
+	return uuid.New().String()
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// TOTP (RFC 6238): HMAC-SHA1, periode 30 detik, 6 digit, kompatibel dengan
+// Google Authenticator / Authy. Semua fungsi menerima waktu sebagai parameter
+// supaya bisa dicek offline dengan jam tetap; handler memakai TOTPClock.
+const (
+	TOTPPeriod = 30
+	TOTPDigits = 6
+	TOTPSkew   = 1 // toleransi ±1 periode untuk jam HP yang meleset
+	TOTPIssuer = "Go API"
+)
+
+// TOTPClock sumber waktu untuk verifikasi TOTP, bisa diganti jam tetap
+var TOTPClock = time.Now
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret membuat secret baru (160 bit, base32 tanpa padding)
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI menyusun otpauth:// URI untuk QR code aplikasi authenticator
+func TOTPProvisioningURI(secret, accountName string) string {
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = TOTPIssuer
+	}
+
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(TOTPDigits))
+	v.Set("period", fmt.Sprint(TOTPPeriod))
+
+	label := url.PathEscape(issuer + ":" + accountName)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// TOTPStep mengembalikan nomor periode untuk waktu t
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / TOTPPeriod
+}
+
+// TOTPCode menghitung kode untuk periode tertentu
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod), nil
+}
+
+// ValidateTOTP cek kode pada waktu t (±TOTPSkew periode). Periode yang sudah
+// dipakai (<= lastStep) ditolak supaya kode tidak bisa dipakai ulang.
+// Return periode yang cocok untuk disimpan sebagai lastStep berikutnya.
+func ValidateTOTP(secret, code string, t time.Time, lastStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	current := TOTPStep(t)
+	for step := current - TOTPSkew; step <= current+TOTPSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// TOTPRequiredForRole cek apakah role wajib 2FA (env TOTP_REQUIRED_ROLES, default admin)
+func TOTPRequiredForRole(role string) bool {
+	roles := os.Getenv("TOTP_REQUIRED_ROLES")
+	if roles == "" {
+		roles = RoleAdmin
+	}
+	for _, r := range strings.Split(roles, ",") {
+		if NormalizeRole(r) == NormalizeRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateRecoveryCodes membuat n kode cadangan format xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		for j := range b {
+			b[j] = alphabet[int(b[j])%len(alphabet)]
+		}
+		codes = append(codes, string(b[:5])+"-"+string(b[5:]))
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode menyamakan input user sebelum di-hash
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, " ", "")
+	code = strings.ReplaceAll(code, "-", "")
+	if len(code) == 10 {
+		code = code[:5] + "-" + code[5:]
+	}
+	return code
+}
+
+// HashRecoveryCode hash yang disimpan untuk recovery code (setelah dinormalisasi)
+func HashRecoveryCode(code string) string {
+	return HashToken(NormalizeRecoveryCode(code))
+}
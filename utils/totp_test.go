@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// secret RFC 6238 untuk SHA-1: ASCII "12345678901234567890" dalam base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// setTOTPClock memakai jam tetap selama test
+func setTOTPClock(t *testing.T, now time.Time) {
+	t.Helper()
+	orig := TOTPClock
+	TOTPClock = func() time.Time { return now }
+	t.Cleanup(func() { TOTPClock = orig })
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// Appendix B RFC 6238 (SHA-1), 6 digit terakhir dari kode 8 digit
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Fatal("expected error for invalid secret")
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	setTOTPClock(t, time.Unix(1111111111, 0))
+	current := TOTPStep(TOTPClock())
+
+	tests := []struct {
+		name  string
+		step  int64
+		valid bool
+	}{
+		{"current step", current, true},
+		{"one step behind", current - 1, true},
+		{"one step ahead", current + 1, true},
+		{"two steps behind", current - 2, false},
+		{"two steps ahead", current + 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := TOTPCode(rfc6238Secret, tt.step)
+			if err != nil {
+				t.Fatal(err)
+			}
+			step, ok := ValidateTOTP(rfc6238Secret, code, TOTPClock(), 0)
+			if ok != tt.valid {
+				t.Fatalf("ValidateTOTP ok = %v, want %v", ok, tt.valid)
+			}
+			if ok && step != tt.step {
+				t.Errorf("ValidateTOTP step = %d, want %d", step, tt.step)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPFormat(t *testing.T) {
+	setTOTPClock(t, time.Unix(1111111111, 0))
+
+	tests := []struct {
+		name  string
+		code  string
+		valid bool
+	}{
+		{"exact", "050471", true},
+		{"spaces", " 050 471 ", true},
+		{"wrong code", "050472", false},
+		{"too short", "50471", false},
+		{"too long", "0050471", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(rfc6238Secret, tt.code, TOTPClock(), 0); ok != tt.valid {
+				t.Errorf("ValidateTOTP(%q) = %v, want %v", tt.code, ok, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPRejectsReplay(t *testing.T) {
+	setTOTPClock(t, time.Unix(1111111111, 0))
+	current := TOTPStep(TOTPClock())
+	code, _ := TOTPCode(rfc6238Secret, current)
+
+	step, ok := ValidateTOTP(rfc6238Secret, code, TOTPClock(), 0)
+	if !ok || step != current {
+		t.Fatalf("first use: ok = %v, step = %d", ok, step)
+	}
+
+	// lastStep disimpan setelah pemakaian pertama, kode yang sama ditolak
+	if _, ok := ValidateTOTP(rfc6238Secret, code, TOTPClock(), step); ok {
+		t.Fatal("same step accepted twice")
+	}
+
+	// kode periode sebelumnya (masih dalam skew) juga ditolak
+	previous, _ := TOTPCode(rfc6238Secret, current-1)
+	if _, ok := ValidateTOTP(rfc6238Secret, previous, TOTPClock(), step); ok {
+		t.Fatal("older step accepted after newer step was used")
+	}
+
+	// periode berikutnya tetap bisa dipakai
+	next, _ := TOTPCode(rfc6238Secret, current+1)
+	if got, ok := ValidateTOTP(rfc6238Secret, next, TOTPClock(), step); !ok || got != current+1 {
+		t.Fatalf("next step: ok = %v, step = %d", ok, got)
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+
+	format := regexp.MustCompile(`^[a-z2-9]{5}-[a-z2-9]{5}$`)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q has wrong format", code)
+		}
+		if NormalizeRecoveryCode(code) != code {
+			t.Errorf("generated code %q is not normalized", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"abcde-fghjk", "abcde-fghjk"},
+		{"ABCDE-FGHJK", "abcde-fghjk"},
+		{"abcdefghjk", "abcde-fghjk"},
+		{" abcde fghjk ", "abcde-fghjk"},
+		{"abc", "abc"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.in); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// recoveryCodeTable tiruan tabel recovery_codes: hash -> sudah dipakai.
+// use meniru UPDATE ... WHERE code_hash = ? AND used_at IS NULL di controllers.useRecoveryCode.
+type recoveryCodeTable map[string]bool
+
+func (tbl recoveryCodeTable) use(code string) bool {
+	hash := HashRecoveryCode(code)
+	used, ok := tbl[hash]
+	if !ok || used {
+		return false
+	}
+	tbl[hash] = true
+	return true
+}
+
+func TestRecoveryCodeSingleUse(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl := recoveryCodeTable{}
+	for _, code := range codes {
+		tbl[HashRecoveryCode(code)] = false
+	}
+
+	// user boleh mengetik huruf besar / tanpa strip
+	typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	if !tbl.use(typed) {
+		t.Fatal("first use rejected")
+	}
+	if tbl.use(codes[0]) {
+		t.Fatal("recovery code accepted twice")
+	}
+	if !tbl.use(codes[1]) {
+		t.Fatal("other recovery code rejected after first was used")
+	}
+	if tbl.use("zzzzz-zzzzz") {
+		t.Fatal("unknown recovery code accepted")
+	}
+}
@@ -3,7 +3,10 @@ package ws
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/ary/go-api/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
@@ -14,7 +17,7 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-func ServeWS(w http.ResponseWriter, r *http.Request, userID string, role string) {
+func ServeWS(w http.ResponseWriter, r *http.Request, auth utils.StreamAuth) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		fmt.Println("WebSocket upgrade error:", err)
@@ -22,10 +25,11 @@ func ServeWS(w http.ResponseWriter, r *http.Request, userID string, role string)
 	}
 
 	client := &Client{
-		UserID: userID,
-		Role:   role,
+		UserID: auth.UserID,
+		Role:   auth.Role,
 		Conn:   conn,
 		Send:   make(chan []byte, 256),
+		auth:   auth,
 	}
 
 	H.Register <- client
@@ -50,28 +54,67 @@ func (c *Client) readPump() {
 }
 
 func (c *Client) writePump() {
+	authCheck := time.NewTicker(utils.StreamAuthCheckInterval)
+	expired := time.NewTimer(time.Until(c.auth.ExpiresAt))
 	defer func() {
+		authCheck.Stop()
+		expired.Stop()
 		c.Conn.Close()
 	}()
 
-	for msg := range c.Send {
-		err := c.Conn.WriteMessage(websocket.TextMessage, msg)
-		if err != nil {
-			break
+	for {
+		select {
+		case msg, ok := <-c.Send:
+			if !ok {
+				return
+			}
+			if err := c.Conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-authCheck.C:
+			if c.auth.StillValid != nil && !c.auth.StillValid() {
+				c.closeUnauthorized("token revoked")
+				return
+			}
+		case <-expired.C:
+			c.closeUnauthorized("token expired")
+			return
 		}
 	}
 }
 
+// closeUnauthorized menutup koneksi dengan close code 4001,
+// supaya client refresh token / minta ticket baru sebelum reconnect
+func (c *Client) closeUnauthorized(reason string) {
+	msg := websocket.FormatCloseMessage(4001, reason)
+	c.Conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+}
+
+// ServeWSHandler membuka koneksi WebSocket. Identitas diambil dari token
+// (header Authorization, cookie access_token, atau ?ticket=).
 func ServeWSHandler(c *gin.Context) {
-	userID := c.Query("user_id")
-	role := c.Query("role")
+	claims, source, err := utils.AuthenticateStream(c)
+	if err != nil || !utils.StreamStillValid(claims) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
 
-	if userID == "" || role == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id and role are required"})
+	// cookie ikut terkirim dari situs mana pun, jadi untuk cookie wajib same-origin
+	if source == utils.StreamAuthCookie && !sameOrigin(c.Request) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Origin not allowed"})
 		return
 	}
 
-	ServeWS(c.Writer, c.Request, userID, role)
+	ServeWS(c.Writer, c.Request, utils.NewStreamAuth(claims))
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
 }
 
 // func ServeWSHandler(c *gin.Context) {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/ary/go-api/utils"
 	"github.com/gorilla/websocket"
 )
 
@@ -12,6 +13,7 @@ type Client struct {
 	Role   string
 	Conn   *websocket.Conn
 	Send   chan []byte
+	auth   utils.StreamAuth
 }
 
 type TargetMessage struct {